@@ -0,0 +1,616 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"image/color"
+	"time"
+	"unsafe"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// Annotation is implemented by the typed annotation values returned by
+// Page.Annotations: *Highlight, *Underline, *StrikeOut, *Square, *Circle,
+// *Ink, *FreeText, *Stamp, *Note, *Line, *Polygon, *FileAttachment and
+// *OtherAnnotation for subtypes without a dedicated type.
+type Annotation interface {
+	info() *AnnotationInfo
+}
+
+// AnnotationInfo holds the properties shared by every annotation. All
+// geometry is expressed in page space.
+type AnnotationInfo struct {
+	// ID is the PDF object number of the annotation. It is assigned by
+	// CreateAnnotation and identifies the annotation for updates.
+	ID       int
+	Rect     gfx.Rect
+	Color    color.Color
+	Opacity  float64
+	Author   string
+	Contents string
+	Created  time.Time
+	Modified time.Time
+}
+
+func (a *AnnotationInfo) info() *AnnotationInfo { return a }
+
+// TextMarkup is the common shape of highlight, underline and strike out
+// annotations.
+type TextMarkup struct {
+	AnnotationInfo
+	Quads []gfx.Quad
+}
+
+type Highlight struct{ TextMarkup }
+type Underline struct{ TextMarkup }
+type StrikeOut struct{ TextMarkup }
+
+type Square struct {
+	AnnotationInfo
+	InteriorColor color.Color
+	BorderWidth   float64
+}
+
+type Circle struct {
+	AnnotationInfo
+	InteriorColor color.Color
+	BorderWidth   float64
+}
+
+type Ink struct {
+	AnnotationInfo
+	BorderWidth float64
+	Strokes     [][]gfx.Point
+}
+
+// Quadding values for FreeText annotations.
+const (
+	QuaddingLeft int = iota
+	QuaddingCenter
+	QuaddingRight
+)
+
+type FreeText struct {
+	AnnotationInfo
+	Font        string
+	FontSize    float64
+	TextColor   color.Color
+	Quadding    int
+	BorderWidth float64
+}
+
+type Stamp struct {
+	AnnotationInfo
+	Icon string
+}
+
+// Note is a text annotation, displayed as an icon that opens a popup.
+type Note struct {
+	AnnotationInfo
+	Icon string
+	Open bool
+}
+
+// LineEnding is the decoration drawn at the end of a line annotation.
+type LineEnding int
+
+const (
+	LineEndingNone LineEnding = iota
+	LineEndingSquare
+	LineEndingCircle
+	LineEndingDiamond
+	LineEndingOpenArrow
+	LineEndingClosedArrow
+	LineEndingButt
+	LineEndingROpenArrow
+	LineEndingRClosedArrow
+	LineEndingSlash
+)
+
+type Line struct {
+	AnnotationInfo
+	Start, End    gfx.Point
+	StartStyle    LineEnding
+	EndStyle      LineEnding
+	InteriorColor color.Color
+	BorderWidth   float64
+}
+
+type Polygon struct {
+	AnnotationInfo
+	Vertices      []gfx.Point
+	InteriorColor color.Color
+	BorderWidth   float64
+}
+
+type FileAttachment struct {
+	AnnotationInfo
+	Icon     string
+	FileName string
+	MimeType string
+	Data     []byte
+}
+
+// OtherAnnotation is returned for annotation subtypes that have no
+// dedicated type. It cannot be created.
+type OtherAnnotation struct {
+	AnnotationInfo
+	Subtype string
+}
+
+// Annotations returns the annotations on the page, excluding links and form
+// widgets.
+func (p *Page) Annotations() (annots []Annotation, err error) {
+	p.lockDocument()
+	defer p.unlockDocument()
+
+	pg, err := p.loadPDFPage()
+	if err != nil {
+		return nil, err
+	}
+	defer C.fz_drop_page(p.ctx, &pg.super)
+	defer catch(&err)
+
+	for annot := C.pdf_first_annot(p.ctx, pg); annot != nil; annot = C.pdf_next_annot(p.ctx, annot) {
+		switch C.pdf_annot_type(p.ctx, annot) {
+		case C.PDF_ANNOT_LINK, C.PDF_ANNOT_POPUP, C.PDF_ANNOT_WIDGET:
+			continue
+		}
+		annots = append(annots, annotFromFitz(p.ctx, annot))
+	}
+
+	return annots, nil
+}
+
+// CreateAnnotation adds a to the page, generates its appearance and sets
+// its ID. An Opacity of zero is treated as fully opaque.
+func (p *Page) CreateAnnotation(a Annotation) (err error) {
+	typ, ok := annotType(a)
+	if !ok {
+		return ErrUnsupportedAnnotation
+	}
+
+	p.lockDocument()
+	defer p.unlockDocument()
+
+	pg, err := p.loadPDFPage()
+	if err != nil {
+		return err
+	}
+	defer C.fz_drop_page(p.ctx, &pg.super)
+	defer catch(&err)
+
+	annot := C.pdf_create_annot(p.ctx, pg, typ)
+	defer C.pdf_drop_annot(p.ctx, annot)
+
+	info := a.info()
+	if info.Created.IsZero() {
+		info.Created = time.Now()
+	}
+
+	applyAnnot(p.ctx, annot, a)
+	C.pdf_update_annot(p.ctx, annot)
+
+	info.ID = int(C.pdf_to_num(p.ctx, annot.obj))
+	p.reload(&pg.super)
+	return nil
+}
+
+// UpdateAnnotation writes the properties of a back to the annotation with
+// the same ID and regenerates its appearance.
+func (p *Page) UpdateAnnotation(a Annotation) (err error) {
+	p.lockDocument()
+	defer p.unlockDocument()
+
+	pg, err := p.loadPDFPage()
+	if err != nil {
+		return err
+	}
+	defer C.fz_drop_page(p.ctx, &pg.super)
+	defer catch(&err)
+
+	annot := findAnnot(p.ctx, pg, a.info().ID)
+	if annot == nil {
+		return ErrAnnotMissing
+	}
+
+	applyAnnot(p.ctx, annot, a)
+	C.pdf_update_annot(p.ctx, annot)
+
+	p.reload(&pg.super)
+	return nil
+}
+
+// DeleteAnnotation removes the annotation with the same ID as a.
+func (p *Page) DeleteAnnotation(a Annotation) (err error) {
+	p.lockDocument()
+	defer p.unlockDocument()
+
+	pg, err := p.loadPDFPage()
+	if err != nil {
+		return err
+	}
+	defer C.fz_drop_page(p.ctx, &pg.super)
+	defer catch(&err)
+
+	annot := findAnnot(p.ctx, pg, a.info().ID)
+	if annot == nil {
+		return ErrAnnotMissing
+	}
+
+	C.pdf_delete_annot(p.ctx, pg, annot)
+
+	p.reload(&pg.super)
+	return nil
+}
+
+func findAnnot(ctx *C.fz_context, pg *C.pdf_page, id int) *C.pdf_annot {
+	if id <= 0 {
+		return nil
+	}
+
+	for annot := C.pdf_first_annot(ctx, pg); annot != nil; annot = C.pdf_next_annot(ctx, annot) {
+		if int(C.pdf_to_num(ctx, annot.obj)) == id {
+			return annot
+		}
+	}
+
+	return nil
+}
+
+func annotType(a Annotation) (C.enum_pdf_annot_type, bool) {
+	switch a.(type) {
+	case *Highlight:
+		return C.PDF_ANNOT_HIGHLIGHT, true
+	case *Underline:
+		return C.PDF_ANNOT_UNDERLINE, true
+	case *StrikeOut:
+		return C.PDF_ANNOT_STRIKE_OUT, true
+	case *Square:
+		return C.PDF_ANNOT_SQUARE, true
+	case *Circle:
+		return C.PDF_ANNOT_CIRCLE, true
+	case *Ink:
+		return C.PDF_ANNOT_INK, true
+	case *FreeText:
+		return C.PDF_ANNOT_FREE_TEXT, true
+	case *Stamp:
+		return C.PDF_ANNOT_STAMP, true
+	case *Note:
+		return C.PDF_ANNOT_TEXT, true
+	case *Line:
+		return C.PDF_ANNOT_LINE, true
+	case *Polygon:
+		return C.PDF_ANNOT_POLYGON, true
+	case *FileAttachment:
+		return C.PDF_ANNOT_FILE_ATTACHMENT, true
+	}
+	return C.PDF_ANNOT_UNKNOWN, false
+}
+
+func annotFromFitz(ctx *C.fz_context, annot *C.pdf_annot) Annotation {
+	info := AnnotationInfo{
+		ID:       int(C.pdf_to_num(ctx, annot.obj)),
+		Rect:     rectFromFitz(C.pdf_annot_rect(ctx, annot)),
+		Color:    annotColor(ctx, annot, false),
+		Opacity:  float64(C.pdf_annot_opacity(ctx, annot)),
+		Contents: C.GoString(C.pdf_annot_contents(ctx, annot)),
+		Created:  timeFromFitz(C.pdf_annot_creation_date(ctx, annot)),
+		Modified: timeFromFitz(C.pdf_annot_modification_date(ctx, annot)),
+	}
+
+	if C.pdf_annot_has_author(ctx, annot) != 0 {
+		info.Author = C.GoString(C.pdf_annot_author(ctx, annot))
+	}
+
+	switch typ := C.pdf_annot_type(ctx, annot); typ {
+	case C.PDF_ANNOT_HIGHLIGHT:
+		return &Highlight{TextMarkup{info, annotQuads(ctx, annot)}}
+	case C.PDF_ANNOT_UNDERLINE:
+		return &Underline{TextMarkup{info, annotQuads(ctx, annot)}}
+	case C.PDF_ANNOT_STRIKE_OUT:
+		return &StrikeOut{TextMarkup{info, annotQuads(ctx, annot)}}
+
+	case C.PDF_ANNOT_SQUARE:
+		return &Square{
+			AnnotationInfo: info,
+			InteriorColor:  annotColor(ctx, annot, true),
+			BorderWidth:    float64(C.pdf_annot_border(ctx, annot)),
+		}
+
+	case C.PDF_ANNOT_CIRCLE:
+		return &Circle{
+			AnnotationInfo: info,
+			InteriorColor:  annotColor(ctx, annot, true),
+			BorderWidth:    float64(C.pdf_annot_border(ctx, annot)),
+		}
+
+	case C.PDF_ANNOT_INK:
+		strokes := make([][]gfx.Point, int(C.pdf_annot_ink_list_count(ctx, annot)))
+		for i := range strokes {
+			n := int(C.pdf_annot_ink_list_stroke_count(ctx, annot, C.int(i)))
+			strokes[i] = make([]gfx.Point, n)
+			for k := 0; k < n; k++ {
+				strokes[i][k] = pointFromFitz(C.pdf_annot_ink_list_stroke_vertex(ctx, annot, C.int(i), C.int(k)))
+			}
+		}
+		return &Ink{
+			AnnotationInfo: info,
+			BorderWidth:    float64(C.pdf_annot_border(ctx, annot)),
+			Strokes:        strokes,
+		}
+
+	case C.PDF_ANNOT_FREE_TEXT:
+		var font *C.char
+		var size C.float
+		var rgb [3]C.float
+		C.pdf_annot_default_appearance(ctx, annot, &font, &size, &rgb[0])
+		return &FreeText{
+			AnnotationInfo: info,
+			Font:           C.GoString(font),
+			FontSize:       float64(size),
+			TextColor:      colorFromFitz(3, rgb[:]),
+			Quadding:       int(C.pdf_annot_quadding(ctx, annot)),
+			BorderWidth:    float64(C.pdf_annot_border(ctx, annot)),
+		}
+
+	case C.PDF_ANNOT_STAMP:
+		return &Stamp{
+			AnnotationInfo: info,
+			Icon:           C.GoString(C.pdf_annot_icon_name(ctx, annot)),
+		}
+
+	case C.PDF_ANNOT_TEXT:
+		return &Note{
+			AnnotationInfo: info,
+			Icon:           C.GoString(C.pdf_annot_icon_name(ctx, annot)),
+			Open:           C.pdf_annot_is_open(ctx, annot) != 0,
+		}
+
+	case C.PDF_ANNOT_LINE:
+		var a, b C.fz_point
+		var start, end C.enum_pdf_line_ending
+		C.pdf_annot_line(ctx, annot, &a, &b)
+		C.pdf_annot_line_ending_styles(ctx, annot, &start, &end)
+		return &Line{
+			AnnotationInfo: info,
+			Start:          pointFromFitz(a),
+			End:            pointFromFitz(b),
+			StartStyle:     LineEnding(start),
+			EndStyle:       LineEnding(end),
+			InteriorColor:  annotColor(ctx, annot, true),
+			BorderWidth:    float64(C.pdf_annot_border(ctx, annot)),
+		}
+
+	case C.PDF_ANNOT_POLYGON:
+		vertices := make([]gfx.Point, int(C.pdf_annot_vertex_count(ctx, annot)))
+		for i := range vertices {
+			vertices[i] = pointFromFitz(C.pdf_annot_vertex(ctx, annot, C.int(i)))
+		}
+		return &Polygon{
+			AnnotationInfo: info,
+			Vertices:       vertices,
+			InteriorColor:  annotColor(ctx, annot, true),
+			BorderWidth:    float64(C.pdf_annot_border(ctx, annot)),
+		}
+
+	case C.PDF_ANNOT_FILE_ATTACHMENT:
+		att := &FileAttachment{
+			AnnotationInfo: info,
+			Icon:           C.GoString(C.pdf_annot_icon_name(ctx, annot)),
+		}
+		fs := C.pdf_dict_get(ctx, annot.obj, pdfName(C.PDF_ENUM_NAME_FS))
+		if C.pdf_is_embedded_file(ctx, fs) != 0 {
+			att.FileName = C.GoString(C.pdf_embedded_file_name(ctx, fs))
+			att.MimeType = C.GoString(C.pdf_embedded_file_type(ctx, fs))
+
+			buf := C.pdf_load_embedded_file(ctx, fs)
+			var data *C.uchar
+			n := C.fz_buffer_storage(ctx, buf, &data)
+			att.Data = C.GoBytes(unsafe.Pointer(data), C.int(n))
+			C.fz_drop_buffer(ctx, buf)
+		}
+		return att
+
+	default:
+		return &OtherAnnotation{
+			AnnotationInfo: info,
+			Subtype:        C.GoString(C.pdf_string_from_annot_type(ctx, typ)),
+		}
+	}
+}
+
+func applyAnnot(ctx *C.fz_context, annot *C.pdf_annot, a Annotation) {
+	info := a.info()
+
+	if !info.Rect.IsEmpty() {
+		C.pdf_set_annot_rect(ctx, annot, rectToFitz(info.Rect))
+	}
+
+	n, col := colorToFitz(info.Color)
+	C.pdf_set_annot_color(ctx, annot, n, &col[0])
+
+	opacity := info.Opacity
+	if opacity <= 0 {
+		opacity = 1
+	}
+	C.pdf_set_annot_opacity(ctx, annot, C.float(opacity))
+
+	contents := C.CString(info.Contents)
+	defer C.free(unsafe.Pointer(contents))
+	C.pdf_set_annot_contents(ctx, annot, contents)
+
+	if C.pdf_annot_has_author(ctx, annot) != 0 {
+		author := C.CString(info.Author)
+		defer C.free(unsafe.Pointer(author))
+		C.pdf_set_annot_author(ctx, annot, author)
+	}
+
+	if !info.Created.IsZero() {
+		C.pdf_set_annot_creation_date(ctx, annot, C.int64_t(info.Created.Unix()))
+	}
+
+	info.Modified = time.Now()
+	C.pdf_set_annot_modification_date(ctx, annot, C.int64_t(info.Modified.Unix()))
+
+	switch a := a.(type) {
+	case *Highlight:
+		setAnnotQuads(ctx, annot, a.Quads)
+	case *Underline:
+		setAnnotQuads(ctx, annot, a.Quads)
+	case *StrikeOut:
+		setAnnotQuads(ctx, annot, a.Quads)
+
+	case *Square:
+		setAnnotInteriorColor(ctx, annot, a.InteriorColor)
+		C.pdf_set_annot_border(ctx, annot, C.float(a.BorderWidth))
+
+	case *Circle:
+		setAnnotInteriorColor(ctx, annot, a.InteriorColor)
+		C.pdf_set_annot_border(ctx, annot, C.float(a.BorderWidth))
+
+	case *Ink:
+		var counts []C.int
+		var points []C.fz_point
+		for _, stroke := range a.Strokes {
+			counts = append(counts, C.int(len(stroke)))
+			for _, pt := range stroke {
+				points = append(points, pointToFitz(pt))
+			}
+		}
+		if len(points) > 0 {
+			C.pdf_set_annot_ink_list(ctx, annot, C.int(len(counts)), &counts[0], &points[0])
+		} else {
+			C.pdf_clear_annot_ink_list(ctx, annot)
+		}
+		C.pdf_set_annot_border(ctx, annot, C.float(a.BorderWidth))
+
+	case *FreeText:
+		font := a.Font
+		if font == "" {
+			font = "Helv"
+		}
+		size := a.FontSize
+		if size <= 0 {
+			size = 12
+		}
+		cfont := C.CString(font)
+		defer C.free(unsafe.Pointer(cfont))
+
+		rgb := rgbToFitz(a.TextColor)
+		C.pdf_set_annot_default_appearance(ctx, annot, cfont, C.float(size), &rgb[0])
+		C.pdf_set_annot_quadding(ctx, annot, C.int(a.Quadding))
+		C.pdf_set_annot_border(ctx, annot, C.float(a.BorderWidth))
+
+	case *Stamp:
+		setAnnotIcon(ctx, annot, a.Icon)
+
+	case *Note:
+		setAnnotIcon(ctx, annot, a.Icon)
+		open := 0
+		if a.Open {
+			open = 1
+		}
+		C.pdf_set_annot_is_open(ctx, annot, C.int(open))
+
+	case *Line:
+		C.pdf_set_annot_line(ctx, annot, pointToFitz(a.Start), pointToFitz(a.End))
+		C.pdf_set_annot_line_ending_styles(ctx, annot, C.enum_pdf_line_ending(a.StartStyle), C.enum_pdf_line_ending(a.EndStyle))
+		setAnnotInteriorColor(ctx, annot, a.InteriorColor)
+		C.pdf_set_annot_border(ctx, annot, C.float(a.BorderWidth))
+
+	case *Polygon:
+		points := make([]C.fz_point, len(a.Vertices))
+		for i, pt := range a.Vertices {
+			points[i] = pointToFitz(pt)
+		}
+		if len(points) > 0 {
+			C.pdf_set_annot_vertices(ctx, annot, C.int(len(points)), &points[0])
+		} else {
+			C.pdf_clear_annot_vertices(ctx, annot)
+		}
+		setAnnotInteriorColor(ctx, annot, a.InteriorColor)
+		C.pdf_set_annot_border(ctx, annot, C.float(a.BorderWidth))
+
+	case *FileAttachment:
+		setAnnotIcon(ctx, annot, a.Icon)
+		if a.FileName != "" {
+			doc := C.pdf_get_bound_document(ctx, annot.obj)
+
+			fileName := C.CString(a.FileName)
+			defer C.free(unsafe.Pointer(fileName))
+
+			var mimeType *C.char
+			if a.MimeType != "" {
+				mimeType = C.CString(a.MimeType)
+				defer C.free(unsafe.Pointer(mimeType))
+			}
+
+			var data *C.uchar
+			if len(a.Data) > 0 {
+				data = (*C.uchar)(unsafe.Pointer(&a.Data[0]))
+			}
+
+			buf := C.fz_new_buffer_from_copied_data(ctx, data, C.size_t(len(a.Data)))
+			defer C.fz_drop_buffer(ctx, buf)
+
+			fs := C.pdf_add_embedded_file(ctx, doc, fileName, mimeType, buf)
+			C.pdf_dict_put_drop(ctx, annot.obj, pdfName(C.PDF_ENUM_NAME_FS), fs)
+			C.pdf_dirty_annot(ctx, annot)
+		}
+	}
+}
+
+func annotQuads(ctx *C.fz_context, annot *C.pdf_annot) []gfx.Quad {
+	quads := make([]gfx.Quad, int(C.pdf_annot_quad_point_count(ctx, annot)))
+	for i := range quads {
+		quads[i] = quadFromFitz(C.pdf_annot_quad_point(ctx, annot, C.int(i)))
+	}
+	return quads
+}
+
+func setAnnotQuads(ctx *C.fz_context, annot *C.pdf_annot, quads []gfx.Quad) {
+	if len(quads) == 0 {
+		C.pdf_clear_annot_quad_points(ctx, annot)
+		return
+	}
+
+	qv := make([]C.fz_quad, len(quads))
+	for i, q := range quads {
+		qv[i] = quadToFitz(q)
+	}
+	C.pdf_set_annot_quad_points(ctx, annot, C.int(len(qv)), &qv[0])
+}
+
+func setAnnotIcon(ctx *C.fz_context, annot *C.pdf_annot, icon string) {
+	if icon == "" {
+		return
+	}
+	name := C.CString(icon)
+	defer C.free(unsafe.Pointer(name))
+	C.pdf_set_annot_icon_name(ctx, annot, name)
+}
+
+func setAnnotInteriorColor(ctx *C.fz_context, annot *C.pdf_annot, col color.Color) {
+	n, c := colorToFitz(col)
+	C.pdf_set_annot_interior_color(ctx, annot, n, &c[0])
+}
+
+func annotColor(ctx *C.fz_context, annot *C.pdf_annot, interior bool) color.Color {
+	var n C.int
+	var c [4]C.float
+	if interior {
+		C.pdf_annot_interior_color(ctx, annot, &n, &c[0])
+	} else {
+		C.pdf_annot_color(ctx, annot, &n, &c[0])
+	}
+	return colorFromFitz(int(n), c[:])
+}
+
+func timeFromFitz(t C.int64_t) time.Time {
+	if t == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(t), 0)
+}
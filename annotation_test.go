@@ -0,0 +1,92 @@
+package fitz_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/bryanmatteson/fitz"
+)
+
+// annotations returns the annotations of the first page of doc.
+func annotations(t *testing.T, doc *fitz.Document) []fitz.Annotation {
+	t.Helper()
+
+	annots, err := loadPage(t, doc, 0).Annotations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return annots
+}
+
+func TestAnnotations(t *testing.T) {
+	doc := openSample(t)
+	pg := loadPage(t, doc, 0)
+
+	// the link and the text field of the sample are not listed
+	if annots := annotations(t, doc); len(annots) != 0 {
+		t.Fatalf("Annotations() = %d annotations, want none", len(annots))
+	}
+
+	square := &fitz.Square{InteriorColor: color.RGBA{0, 0, 0xff, 0xff}, BorderWidth: 2}
+	square.Rect = rectWH(100, 300, 100, 50)
+	square.Color = color.RGBA{0xff, 0, 0, 0xff}
+	square.Contents = "box"
+
+	note := &fitz.Note{Icon: "Comment"}
+	note.Rect = rectWH(400, 100, 20, 20)
+	note.Contents = "note"
+
+	for _, a := range []fitz.Annotation{square, note} {
+		if err := pg.CreateAnnotation(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if square.ID <= 0 || note.ID <= 0 || square.ID == note.ID {
+		t.Fatalf("CreateAnnotation() IDs = %d, %d, want distinct object numbers", square.ID, note.ID)
+	}
+	if err := pg.CreateAnnotation(&fitz.OtherAnnotation{Subtype: "Sound"}); err != fitz.ErrUnsupportedAnnotation {
+		t.Errorf("CreateAnnotation() of a Sound annotation = %v, want %v", err, fitz.ErrUnsupportedAnnotation)
+	}
+
+	annots := annotations(t, reopen(t, doc))
+	if len(annots) != 2 {
+		t.Fatalf("Annotations() after CreateAnnotation = %d annotations, want 2", len(annots))
+	}
+	for _, a := range annots {
+		switch a := a.(type) {
+		case *fitz.Square:
+			if a.ID != square.ID || a.Contents != "box" || a.BorderWidth != 2 || !rectNear(a.Rect, square.Rect) {
+				t.Errorf("Annotations() square = %+v, want %+v", a, square)
+			}
+			if r, g, b, _ := a.Color.RGBA(); r != 0xffff || g != 0 || b != 0 {
+				t.Errorf("Annotations() square color = %v, want red", a.Color)
+			}
+		case *fitz.Note:
+			if a.ID != note.ID || a.Contents != "note" || a.Icon != "Comment" {
+				t.Errorf("Annotations() note = %+v, want %+v", a, note)
+			}
+		default:
+			t.Errorf("Annotations() = %T, want *fitz.Square or *fitz.Note", a)
+		}
+	}
+
+	square.Contents = "changed"
+	square.BorderWidth = 4
+	if err := pg.UpdateAnnotation(square); err != nil {
+		t.Fatal(err)
+	}
+	if err := pg.DeleteAnnotation(note); err != nil {
+		t.Fatal(err)
+	}
+	if err := pg.DeleteAnnotation(note); err != fitz.ErrAnnotMissing {
+		t.Errorf("DeleteAnnotation() of a deleted annotation = %v, want %v", err, fitz.ErrAnnotMissing)
+	}
+
+	annots = annotations(t, reopen(t, doc))
+	if len(annots) != 1 {
+		t.Fatalf("Annotations() after DeleteAnnotation = %d annotations, want 1", len(annots))
+	}
+	if a, ok := annots[0].(*fitz.Square); !ok || a.Contents != "changed" || a.BorderWidth != 4 {
+		t.Errorf("Annotations() after UpdateAnnotation = %+v, want %+v", annots[0], square)
+	}
+}
@@ -50,6 +50,7 @@ extern void gooutput_writer_close(fz_context* ctx, void* state);
 extern void gooutput_writer_drop(fz_context* ctx, void* state);
 extern void gooutput_writer_seek(fz_context* ctx, void* state, int64_t offset, int whence);
 extern int64_t gooutput_writer_tell(fz_context* ctx, void* state);
+extern int gooutput_stream_write(fz_context* ctx, void* state, const void* data, size_t n);
 
 extern int fzgo_read_stream_next(fz_context* ctx, fz_stream* stm, size_t max);
 extern void fzgo_read_stream_seek(fz_context* ctx, fz_stream* stm, int64_t offset, int whence);
@@ -62,6 +63,15 @@ fz_output* fzgo_new_output_writer(fz_context* ctx, int bufsize, void* iowriter)
     return output;
 }
 
+static void fzgo_stream_write(fz_context* ctx, void* state, const void* data, size_t n) {
+    if (gooutput_stream_write(ctx, state, data, n))
+        fz_throw(ctx, FZ_ERROR_GENERIC, "cannot write to output");
+}
+
+fz_output* fzgo_new_stream_output_writer(fz_context* ctx, int bufsize, void* iowriter) {
+    return fz_new_output(ctx, bufsize, iowriter, fzgo_stream_write, gooutput_writer_close, gooutput_writer_drop);
+}
+
 fz_stream* fzgo_new_read_stream(fz_context* ctx, void* state) {
     fz_stream* stream = fz_new_stream(ctx, state, fzgo_read_stream_next, fzgo_read_stream_drop);
     stream->seek = fzgo_read_stream_seek;
@@ -132,6 +142,26 @@ int fz_text_span_wmode(fz_text_span* span) {
     return span->wmode;
 }
 
+int fz_text_span_bidi_level(fz_text_span* span) {
+    return span->bidi_level;
+}
+
 pdf_obj* pdfname(int typ) {
     return (pdf_obj*)((intptr_t)typ);
-}
\ No newline at end of file
+}
+fz_stext_line* fzgo_stext_block_first_line(fz_stext_block* block) {
+    return block->type == FZ_STEXT_BLOCK_TEXT ? block->u.t.first_line : NULL;
+}
+
+fz_image* fzgo_stext_block_image(fz_stext_block* block) {
+    return block->type == FZ_STEXT_BLOCK_IMAGE ? block->u.i.image : NULL;
+}
+
+fz_matrix fzgo_stext_block_transform(fz_stext_block* block) {
+    return block->type == FZ_STEXT_BLOCK_IMAGE ? block->u.i.transform : fz_identity;
+}
+
+void fzgo_stext_block_set_transform(fz_stext_block* block, fz_matrix transform) {
+    if (block->type == FZ_STEXT_BLOCK_IMAGE)
+        block->u.i.transform = transform;
+}
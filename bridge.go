@@ -40,6 +40,15 @@ func fzgo_stroke_path(ctx *C.fz_context, dev *C.fz_device, path *C.cfz_path_t, s
 	rgb := getRGBColor(ctx, color, colorspace, alpha, colorParams)
 	s := getStroke(stroke)
 
+	if rs, ok := device.(rulingStroker); ok {
+		w := &rulingWalker{ctm: matrix}
+		ref := pointer.Save(w)
+		C.fz_walk_path(ctx, path, &C.go_path_walker, ref)
+		pointer.Unref(ref)
+		rs.strokeRulings(w, matrix.TransformRect(p.Bounds()))
+		return
+	}
+
 	device.StrokePath(p, s, matrix, rgb)
 }
 
@@ -143,7 +152,7 @@ func fzgo_fill_text(ctx *C.fz_context, dev *C.fz_device, text *C.cfz_text_t, ctm
 	}
 
 	rgb := getRGBColor(ctx, color, colorspace, alpha, colorParams)
-	txt := getTextInfo(ctx, text, ctm, rgb)
+	txt := getTextInfo(ctx, text, ctm, rgb, float64(alpha), RenderFill)
 	matrix := gfx.NewMatrix(float64(ctm.a), float64(ctm.b), float64(ctm.c), float64(ctm.d), float64(ctm.e), float64(ctm.f))
 
 	device.FillText(txt, matrix, rgb)
@@ -157,7 +166,7 @@ func fzgo_stroke_text(ctx *C.fz_context, dev *C.fz_device, text *C.cfz_text_t, s
 	}
 
 	rgb := getRGBColor(ctx, color, colorspace, alpha, colorParams)
-	txt := getTextInfo(ctx, text, ctm, rgb)
+	txt := getTextInfo(ctx, text, ctm, rgb, float64(alpha), RenderStroke)
 	s := getStroke(stroke)
 	matrix := gfx.NewMatrix(float64(ctm.a), float64(ctm.b), float64(ctm.c), float64(ctm.d), float64(ctm.e), float64(ctm.f))
 
@@ -173,7 +182,7 @@ func fzgo_clip_text(ctx *C.fz_context, dev *C.fz_device, text *C.cfz_text_t, ctm
 
 	matrix := gfx.NewMatrix(float64(ctm.a), float64(ctm.b), float64(ctm.c), float64(ctm.d), float64(ctm.e), float64(ctm.f))
 	sci := rectFromFitz(scissor)
-	txt := getTextInfo(ctx, text, ctm, nil)
+	txt := getTextInfo(ctx, text, ctm, nil, 1, RenderClip)
 
 	device.ClipText(txt, matrix, sci)
 }
@@ -188,7 +197,7 @@ func fzgo_clip_stroke_text(ctx *C.fz_context, dev *C.fz_device, text *C.cfz_text
 	matrix := gfx.NewMatrix(float64(ctm.a), float64(ctm.b), float64(ctm.c), float64(ctm.d), float64(ctm.e), float64(ctm.f))
 	sci := rectFromFitz(scissor)
 	s := getStroke(stroke)
-	txt := getTextInfo(ctx, text, ctm, nil)
+	txt := getTextInfo(ctx, text, ctm, nil, 1, RenderClipStroke)
 
 	device.ClipStrokeText(txt, s, matrix, sci)
 }
@@ -201,7 +210,7 @@ func fzgo_ignore_text(ctx *C.fz_context, dev *C.fz_device, text *C.cfz_text_t, c
 	}
 
 	matrix := gfx.NewMatrix(float64(ctm.a), float64(ctm.b), float64(ctm.c), float64(ctm.d), float64(ctm.e), float64(ctm.f))
-	txt := getTextInfo(ctx, text, ctm, nil)
+	txt := getTextInfo(ctx, text, ctm, nil, 0, RenderInvisible)
 
 	device.IgnoreText(txt, matrix)
 }
@@ -323,33 +332,44 @@ func fzgo_drop_device(ctx *C.fz_context, dev *C.fz_device) {
 	// device := pointer.Restore(((*C.fzgo_device)(unsafe.Pointer(dev))).user_data).(Device)
 }
 
+// pathWalker receives the segments of a path walked by fz_walk_path. It is
+// the part of gfx.PathWalker that mupdf paths need, so that walkers other
+// than gfx.Path can be used.
+type pathWalker interface {
+	MoveTo(x, y float64)
+	LineTo(x, y float64)
+	CubicCurveTo(x1, y1, x2, y2, x3, y3 float64)
+	QuadCurveTo(x1, y1, x2, y2 float64)
+	Close()
+}
+
 //export gopath_moveto
 func gopath_moveto(ctx *C.fz_context, arg *C.void, x C.float, y C.float) {
-	walker := pointer.Restore(unsafe.Pointer(arg)).(gfx.PathWalker)
+	walker := pointer.Restore(unsafe.Pointer(arg)).(pathWalker)
 	walker.MoveTo(float64(x), float64(y))
 }
 
 //export gopath_lineto
 func gopath_lineto(ctx *C.fz_context, arg *C.void, x C.float, y C.float) {
-	walker := pointer.Restore(unsafe.Pointer(arg)).(gfx.PathWalker)
+	walker := pointer.Restore(unsafe.Pointer(arg)).(pathWalker)
 	walker.LineTo(float64(x), float64(y))
 }
 
 //export gopath_curveto
 func gopath_curveto(ctx *C.fz_context, arg *C.void, x1 C.float, y1 C.float, x2 C.float, y2 C.float, x3 C.float, y3 C.float) {
-	walker := pointer.Restore(unsafe.Pointer(arg)).(gfx.PathWalker)
+	walker := pointer.Restore(unsafe.Pointer(arg)).(pathWalker)
 	walker.CubicCurveTo(float64(x1), float64(y1), float64(x2), float64(y2), float64(x3), float64(y3))
 }
 
 //export gopath_quadto
 func gopath_quadto(ctx *C.fz_context, arg *C.void, x1 C.float, y1 C.float, x2 C.float, y2 C.float) {
-	walker := pointer.Restore(unsafe.Pointer(arg)).(gfx.PathWalker)
+	walker := pointer.Restore(unsafe.Pointer(arg)).(pathWalker)
 	walker.QuadCurveTo(float64(x1), float64(y1), float64(x2), float64(y2))
 }
 
 //export gopath_closepath
 func gopath_closepath(ctx *C.fz_context, arg *C.void) {
-	walker := pointer.Restore(unsafe.Pointer(arg)).(gfx.PathWalker)
+	walker := pointer.Restore(unsafe.Pointer(arg)).(pathWalker)
 	walker.Close()
 }
 
@@ -21,7 +21,13 @@ fz_context* fzgo_new_user_context(void* user);
 fz_device* fz_new_go_device(fz_context* ctx, void* user_data);
 pdf_obj* pdfname(int typ);
 int fz_text_span_wmode(fz_text_span* span);
+int fz_text_span_bidi_level(fz_text_span* span);
 fz_output* fzgo_new_output_writer(fz_context* ctx, int bufsize, void* iowriter);
+fz_output* fzgo_new_stream_output_writer(fz_context* ctx, int bufsize, void* iowriter);
+fz_stext_line* fzgo_stext_block_first_line(fz_stext_block* block);
+fz_image* fzgo_stext_block_image(fz_stext_block* block);
+fz_matrix fzgo_stext_block_transform(fz_stext_block* block);
+void fzgo_stext_block_set_transform(fz_stext_block* block, fz_matrix transform);
 
 typedef struct fzgo_device {
     fz_device super;
@@ -0,0 +1,111 @@
+package fitz
+
+// #include "bridge.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"context"
+	"time"
+	"unsafe"
+)
+
+// ProgressFunc receives the progress of a long running operation. Total is
+// zero when it is not known.
+type ProgressFunc func(done, total int)
+
+// RunOptions controls RunDeviceContext, RenderSVGContext and
+// GetTextContext.
+type RunOptions struct {
+	// Progress, if set, is called from another goroutine with the progress
+	// of the run.
+	Progress ProgressFunc
+}
+
+// progressInterval is how often progress is reported.
+const progressInterval = 100 * time.Millisecond
+
+// cookie is a fz_cookie tied to a context. mupdf polls it while running a
+// display list, so cancelling the context aborts the operation.
+type cookie struct {
+	ptr      *C.fz_cookie
+	ctx      context.Context
+	progress ProgressFunc
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// newCookie returns a cookie that is aborted when ctx is done and reports to
+// progress if it is not nil. The cookie must be closed once the operation
+// has finished.
+func newCookie(ctx context.Context, progress ProgressFunc) *cookie {
+	c := &cookie{
+		// allocated in C since mupdf and the watcher share it
+		ptr:      (*C.fz_cookie)(C.calloc(1, C.sizeof_fz_cookie)),
+		ctx:      ctx,
+		progress: progress,
+	}
+
+	if ctx.Err() != nil {
+		c.ptr.abort = 1
+		return c
+	}
+
+	if ctx.Done() != nil || c.progress != nil {
+		c.stop = make(chan struct{})
+		c.stopped = make(chan struct{})
+		go c.watch()
+	}
+	return c
+}
+
+func (c *cookie) watch() {
+	defer close(c.stopped)
+
+	var tick <-chan time.Time
+	if c.progress != nil {
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			c.ptr.abort = 1
+			return
+		case <-tick:
+			c.report()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *cookie) report() {
+	total := int(c.ptr.progress_max)
+	if c.ptr.progress_max == ^C.size_t(0) {
+		total = 0
+	}
+	c.progress(int(c.ptr.progress), total)
+}
+
+// err returns the error of the context if the operation was aborted.
+func (c *cookie) err() error {
+	if c.ptr.abort != 0 {
+		return c.ctx.Err()
+	}
+	return nil
+}
+
+// close stops watching the context, reports the final progress and frees
+// the cookie.
+func (c *cookie) close() {
+	if c.stop != nil {
+		close(c.stop)
+		<-c.stopped
+	}
+	if c.progress != nil && c.ptr.abort == 0 {
+		c.report()
+	}
+	C.free(unsafe.Pointer(c.ptr))
+}
@@ -0,0 +1,344 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// TileSink stores the files of a tile pyramid. WriteFile is called from
+// several goroutines at once.
+type TileSink interface {
+	WriteFile(name string, data []byte) error
+}
+
+// DirSink writes files below a directory, creating subdirectories as
+// needed.
+type DirSink string
+
+func (dir DirSink) WriteFile(name string, data []byte) error {
+	path := filepath.Join(string(dir), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ZipSink writes files into a zip archive. Close must be called to finish
+// the archive.
+type ZipSink struct {
+	mut sync.Mutex
+	zw  *zip.Writer
+}
+
+func NewZipSink(w io.Writer) *ZipSink {
+	return &ZipSink{zw: zip.NewWriter(w)}
+}
+
+func (s *ZipSink) WriteFile(name string, data []byte) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	// tiles are compressed already
+	f, err := s.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+func (s *ZipSink) Close() error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.zw.Close()
+}
+
+// MapSink keeps files in memory, keyed by name.
+type MapSink struct {
+	mut   sync.Mutex
+	Files map[string][]byte
+}
+
+func (s *MapSink) WriteFile(name string, data []byte) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.Files == nil {
+		s.Files = make(map[string][]byte)
+	}
+	s.Files[name] = data
+	return nil
+}
+
+// DeepZoomOptions controls Document.DeepZoom.
+type DeepZoomOptions struct {
+	// DPI is the resolution of the deepest level. Defaults to 144.
+	DPI float64
+	// TileSize is the width and height of tiles in pixels. Defaults to 256.
+	TileSize int
+	// Overlap is how many pixels tiles overlap their neighbours on each
+	// side.
+	Overlap int
+	// Format is the image format of the tiles, "png" or "jpg". Defaults to
+	// "png".
+	Format string
+	// Quality is the JPEG quality. Defaults to 90.
+	Quality int
+	// Pages lists the zero-based pages to render. If empty, every page is
+	// rendered.
+	Pages []int
+	// Workers is the number of levels rendered at once. Defaults to the
+	// number of CPUs.
+	Workers int
+}
+
+func (o DeepZoomOptions) withDefaults() DeepZoomOptions {
+	if o.DPI <= 0 {
+		o.DPI = 144
+	}
+	if o.TileSize <= 0 {
+		o.TileSize = 256
+	}
+	if o.Format == "" {
+		o.Format = "png"
+	}
+	if o.Quality <= 0 {
+		o.Quality = 90
+	}
+	if o.Workers <= 0 {
+		o.Workers = runtime.NumCPU()
+	}
+	return o
+}
+
+// dziImage is the Deep Zoom descriptor of a page.
+type dziImage struct {
+	XMLName  xml.Name `xml:"http://schemas.microsoft.com/deepzoom/2008 Image"`
+	Format   string   `xml:"Format,attr"`
+	Overlap  int      `xml:"Overlap,attr"`
+	TileSize int      `xml:"TileSize,attr"`
+	Size     struct {
+		Width  int `xml:"Width,attr"`
+		Height int `xml:"Height,attr"`
+	} `xml:"Size"`
+}
+
+// pyramidLevel is one zoom level of a page, rendered by a single worker.
+type pyramidLevel struct {
+	list   *C.fz_display_list
+	bounds C.fz_rect
+	name   string
+	level  int
+	scale  float64
+	width  int
+	height int
+}
+
+// DeepZoom renders a Deep Zoom tile pyramid for each page. Page n is
+// described by "page-n.dzi", with its tiles stored as
+// "page-n_files/<level>/<col>_<row>.<format>". Levels are rendered in
+// parallel from the display lists of the pages, each worker using its own
+// context.
+func (d *Document) DeepZoom(ctx context.Context, sink TileSink, opts DeepZoomOptions) error {
+	opts = opts.withDefaults()
+	if opts.Format != "png" && opts.Format != "jpg" {
+		return ErrUnknownFormat
+	}
+
+	pages := opts.Pages
+	if len(pages) == 0 {
+		for i, n := 0, d.NumPages(); i < n; i++ {
+			pages = append(pages, i)
+		}
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	levels := make(chan pyramidLevel)
+
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// every goroutine needs its own context
+			fzctx := C.fz_clone_context(d.ctx)
+			defer C.fz_drop_context(fzctx)
+
+			for level := range levels {
+				if err := level.render(ctx, fzctx, sink, opts); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	for _, num := range pages {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := d.queuePyramid(ctx, num, sink, opts, levels); err != nil {
+			fail(err)
+			break
+		}
+	}
+	close(levels)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return parent.Err()
+}
+
+// queuePyramid writes the descriptor of a page and queues its levels,
+// deepest first.
+func (d *Document) queuePyramid(ctx context.Context, num int, sink TileSink, opts DeepZoomOptions, levels chan<- pyramidLevel) error {
+	page, err := d.LoadPage(num)
+	if err != nil {
+		return err
+	}
+
+	page.mut.Lock()
+	bounds := page.bounds
+	list := page.list
+	page.mut.Unlock()
+
+	scale := opts.DPI / 72
+	width := int(math.Ceil(float64(bounds.x1-bounds.x0) * scale))
+	height := int(math.Ceil(float64(bounds.y1-bounds.y0) * scale))
+
+	var dzi dziImage
+	dzi.Format = opts.Format
+	dzi.Overlap = opts.Overlap
+	dzi.TileSize = opts.TileSize
+	dzi.Size.Width = width
+	dzi.Size.Height = height
+
+	data, err := xml.MarshalIndent(dzi, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("page-%d", num+1)
+	if err := sink.WriteFile(name+".dzi", append([]byte(xml.Header), data...)); err != nil {
+		return err
+	}
+
+	maxLevel := 0
+	if size := math.Max(float64(width), float64(height)); size > 1 {
+		maxLevel = int(math.Ceil(math.Log2(size)))
+	}
+
+	for level := maxLevel; level >= 0; level-- {
+		factor := math.Exp2(float64(maxLevel - level))
+
+		page.mut.Lock()
+		C.fz_keep_display_list(page.ctx, list)
+		page.mut.Unlock()
+
+		l := pyramidLevel{
+			list:   list,
+			bounds: bounds,
+			name:   name,
+			level:  level,
+			scale:  scale / factor,
+			width:  int(math.Ceil(float64(width) / factor)),
+			height: int(math.Ceil(float64(height) / factor)),
+		}
+
+		select {
+		case levels <- l:
+		case <-ctx.Done():
+			C.fz_drop_display_list(page.ctx, list)
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (l pyramidLevel) render(ctx context.Context, fzctx *C.fz_context, sink TileSink, opts DeepZoomOptions) (err error) {
+	defer C.fz_drop_display_list(fzctx, l.list)
+	defer catch(&err)
+
+	ctm := C.fz_concat(C.fz_translate(-l.bounds.x0, -l.bounds.y0), C.fz_scale(C.float(l.scale), C.float(l.scale)))
+	full := image.Rect(0, 0, l.width, l.height)
+	size := opts.TileSize
+
+	for row := 0; row*size < l.height; row++ {
+		for col := 0; col*size < l.width; col++ {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			r := image.Rect(col*size-opts.Overlap, row*size-opts.Overlap, (col+1)*size+opts.Overlap, (row+1)*size+opts.Overlap).Intersect(full)
+			data, err := encodeTile(ctx, fzctx, l.list, ctm, r, opts)
+			if err != nil {
+				return err
+			}
+
+			name := fmt.Sprintf("%s_files/%d/%d_%d.%s", l.name, l.level, col, row, opts.Format)
+			if err := sink.WriteFile(name, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func encodeTile(ctx context.Context, fzctx *C.fz_context, list *C.fz_display_list, ctm C.fz_matrix, r image.Rectangle, opts DeepZoomOptions) ([]byte, error) {
+	cookie := newCookie(ctx, nil)
+	defer cookie.close()
+
+	bbox := C.fz_make_irect(C.int(r.Min.X), C.int(r.Min.Y), C.int(r.Max.X), C.int(r.Max.Y))
+	pixmap := renderImagePixmap(fzctx, list, ctm, bbox, RenderOptions{}, cookie.ptr)
+	if pixmap == nil {
+		return nil, ErrCreatePixmap
+	}
+	defer C.fz_drop_pixmap(fzctx, pixmap)
+
+	if err := cookie.err(); err != nil {
+		return nil, err
+	}
+
+	img, err := imageFromPixmap(fzctx, pixmap, ColorspaceRGB, image.Point{})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if opts.Format == "jpg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: opts.Quality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	return buf.Bytes(), err
+}
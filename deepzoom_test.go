@@ -0,0 +1,83 @@
+package fitz_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"image/png"
+	"testing"
+
+	"github.com/bryanmatteson/fitz"
+)
+
+func TestDeepZoom(t *testing.T) {
+	doc := openSample(t)
+
+	// at 18 dpi the 612×792pt page is 153×198 pixels, so there are nine
+	// levels. The deepest has 3×4 tiles of 64 pixels, the next 2×2 and the
+	// rest one each.
+	sink := &fitz.MapSink{}
+	opts := fitz.DeepZoomOptions{DPI: 18, TileSize: 64, Overlap: 1, Pages: []int{1}}
+	if err := doc.DeepZoom(context.Background(), sink, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.Files) != 1+12+4+7 {
+		t.Errorf("DeepZoom() wrote %d files, want %d", len(sink.Files), 1+12+4+7)
+	}
+
+	var dzi struct {
+		TileSize int `xml:"TileSize,attr"`
+		Overlap  int `xml:"Overlap,attr"`
+		Size     struct {
+			Width  int `xml:"Width,attr"`
+			Height int `xml:"Height,attr"`
+		} `xml:"Size"`
+	}
+	if err := xml.Unmarshal(sink.Files["page-2.dzi"], &dzi); err != nil {
+		t.Fatalf("DeepZoom() descriptor: %v", err)
+	}
+	if dzi.TileSize != 64 || dzi.Overlap != 1 || dzi.Size.Width != 153 || dzi.Size.Height != 198 {
+		t.Errorf("DeepZoom() descriptor = %+v, want 64 pixel tiles overlapping by 1 of a 153×198 image", dzi)
+	}
+
+	tiles := []struct {
+		name          string
+		width, height int
+	}{
+		{"page-2_files/8/0_0.png", 65, 65},
+		{"page-2_files/8/1_1.png", 66, 66},
+		{"page-2_files/8/2_3.png", 26, 7},
+		{"page-2_files/7/1_1.png", 14, 36},
+		{"page-2_files/0/0_0.png", 1, 1},
+	}
+	for _, tt := range tiles {
+		data, ok := sink.Files[tt.name]
+		if !ok {
+			t.Errorf("DeepZoom() did not write %s", tt.name)
+			continue
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("DeepZoom() %s: %v", tt.name, err)
+			continue
+		}
+		if b := img.Bounds(); b.Dx() != tt.width || b.Dy() != tt.height {
+			t.Errorf("DeepZoom() %s is %d×%d, want %d×%d", tt.name, b.Dx(), b.Dy(), tt.width, tt.height)
+		}
+	}
+}
+
+func TestDeepZoomErrors(t *testing.T) {
+	doc := openSample(t)
+
+	if err := doc.DeepZoom(context.Background(), &fitz.MapSink{}, fitz.DeepZoomOptions{Format: "gif"}); err != fitz.ErrUnknownFormat {
+		t.Errorf("DeepZoom() to gif = %v, want %v", err, fitz.ErrUnknownFormat)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := doc.DeepZoom(ctx, &fitz.MapSink{}, fitz.DeepZoomOptions{}); err != context.Canceled {
+		t.Errorf("DeepZoom() with a cancelled context = %v, want %v", err, context.Canceled)
+	}
+}
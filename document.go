@@ -20,10 +20,15 @@ import (
 type Document struct {
 	mut    sync.Mutex
 	ctx    *C.fz_context
-	native *C.pdf_document
+	native *C.fz_document
+	pdf    *C.pdf_document
 	pages  map[int]*Page
 }
 
+// IsPDF reports whether the underlying document is a PDF. Features that
+// operate on the PDF object model return ErrNotPDF for other formats.
+func (d *Document) IsPDF() bool { return d.pdf != nil }
+
 func (d *Document) GetFontCache() gfx.FontCache {
 	return pointer.Restore(unsafe.Pointer(d.ctx.user)).(*usercontext).fontCache
 }
@@ -31,19 +36,19 @@ func (d *Document) GetFontCache() gfx.FontCache {
 func (d *Document) NumPages() int {
 	d.mut.Lock()
 	defer d.mut.Unlock()
-	return int(C.fz_count_pages(d.ctx, &d.native.super))
+	return int(C.fz_count_pages(d.ctx, d.native))
 }
 
 func (d *Document) LoadPage(num int) (*Page, error) {
 	d.mut.Lock()
 	defer d.mut.Unlock()
 
-	if int(C.fz_count_pages(d.ctx, &d.native.super)) <= num {
+	if int(C.fz_count_pages(d.ctx, d.native)) <= num {
 		return nil, ErrInvalidPage
 	}
 
 	if _, ok := d.pages[num]; !ok {
-		d.pages[num] = newPage(d.native, d.ctx, num)
+		d.pages[num] = newPage(d.native, d.ctx, &d.mut, num)
 	}
 
 	return d.pages[num], nil
@@ -88,7 +93,7 @@ func (d *Document) Close() {
 		pg.drop()
 	}
 	d.pages = nil
-	C.pdf_drop_document(d.ctx, d.native)
+	C.fz_drop_document(d.ctx, d.native)
 
 	if d.ctx.user != nil {
 		pointer.Unref(unsafe.Pointer(d.ctx.user))
@@ -99,6 +104,10 @@ func (d *Document) Close() {
 }
 
 func (d *Document) Save(filePath string, opts WriteOptions) error {
+	if d.pdf == nil {
+		return ErrNotPDF
+	}
+
 	file, err := os.Create(filePath)
 	if err != nil {
 		return err
@@ -109,47 +118,108 @@ func (d *Document) Save(filePath string, opts WriteOptions) error {
 	output := C.CString(filePath)
 	defer C.free(unsafe.Pointer(output))
 
-	C.pdf_save_document(d.ctx, d.native, output, &options)
+	C.pdf_save_document(d.ctx, d.pdf, output, &options)
 	return nil
 }
 
 func (d *Document) Write(w io.Writer, opts WriteOptions) error {
+	if d.pdf == nil {
+		return ErrNotPDF
+	}
+
 	options := opts.fzoptions()
 	output := newOutputForWriter(d.ctx, 8192, w)
 	defer C.fz_drop_output(d.ctx, output)
 
-	C.pdf_write_document(d.ctx, d.native, output, &options)
+	C.pdf_write_document(d.ctx, d.pdf, output, &options)
 	C.fz_close_output(d.ctx, output)
 
 	return nil
 }
 
+// NewDocumentFromPages creates a new PDF containing the given pages in
+// order. Outline entries that point at one of the copied pages are carried
+// over with their page numbers remapped. If the outline cannot be read, the
+// new document has none.
 func (d *Document) NewDocumentFromPages(pages ...int) (*Document, error) {
+	if d.pdf == nil {
+		return nil, ErrNotPDF
+	}
+
+	// a broken outline is not worth losing the pages over
+	outline, err := d.Outline()
+	if err != nil {
+		outline = nil
+	}
+
 	dest := C.pdf_create_document(d.ctx)
 
 	graftMap := C.pdf_new_graft_map(d.ctx, dest)
 	defer C.pdf_drop_graft_map(d.ctx, graftMap)
 
-	for _, pg := range pages {
-		C.pdf_graft_mapped_page(d.ctx, graftMap, C.int(-1), d.native, C.int(pg))
+	pageMap := make(map[int]int, len(pages))
+	for i, pg := range pages {
+		C.pdf_graft_mapped_page(d.ctx, graftMap, C.int(-1), d.pdf, C.int(pg))
+		if _, ok := pageMap[pg]; !ok {
+			pageMap[pg] = i
+		}
 	}
 
-	return newDocument(C.fz_clone_context(d.ctx), dest), nil
+	doc := newDocument(C.fz_clone_context(d.ctx), &dest.super)
+	if err := doc.SetOutline(remapOutline(outline, pageMap)); err != nil {
+		// the cloned context shares our user data, so it cannot go
+		// through Close
+		C.pdf_drop_document(doc.ctx, dest)
+		C.fz_drop_context(doc.ctx)
+		return nil, err
+	}
+
+	return doc, nil
 }
 
-func newDocument(ctx *C.fz_context, doc *C.pdf_document) *Document {
+func newDocument(ctx *C.fz_context, doc *C.fz_document) *Document {
 	return &Document{
 		ctx:    ctx,
 		native: doc,
+		pdf:    C.pdf_specifics(ctx, doc),
 		pages:  make(map[int]*Page),
 	}
 }
 
+// OpenOptions controls how a document is opened.
+type OpenOptions struct {
+	// Type is a mime type or file extension (e.g. "application/epub+zip",
+	// "xps", "cbz", "png") used to select the document handler when
+	// reading from a stream. Defaults to "application/pdf".
+	Type string
+	// Password is tried against encrypted documents. If empty and the
+	// document needs a password, ErrNeedsPassword is returned.
+	Password string
+}
+
 func NewDocumentFromBytes(b []byte) (d *Document, err error) {
 	return NewDocument(bytes.NewReader(b))
 }
 
+// NewDocumentFromBytesWithType opens b as a document of the given type.
+func NewDocumentFromBytesWithType(b []byte, magic string) (d *Document, err error) {
+	return NewDocumentWithType(bytes.NewReader(b), magic)
+}
+
+// NewDocument opens a PDF document from r.
 func NewDocument(r io.Reader) (d *Document, err error) {
+	return NewDocumentWithOptions(r, OpenOptions{})
+}
+
+// NewDocumentWithType opens a document from r using magic, a mime type or
+// file extension (e.g. "application/epub+zip", "xps", "cbz", "png"), to
+// select the document handler.
+func NewDocumentWithType(r io.Reader, magic string) (d *Document, err error) {
+	return NewDocumentWithOptions(r, OpenOptions{Type: magic})
+}
+
+// NewDocumentWithOptions opens a document from r.
+func NewDocumentWithOptions(r io.Reader, opts OpenOptions) (d *Document, err error) {
 	ctx := C.fzgo_new_context()
 
 	if err != nil {
@@ -175,28 +245,30 @@ func NewDocument(r io.Reader) (d *Document, err error) {
 
 	defer C.fz_drop_stream(ctx, stream)
 
-	native := C.pdf_open_document_with_stream(ctx, stream)
+	magic := opts.Type
+	if magic == "" {
+		magic = "application/pdf"
+	}
+
+	cmagic := C.CString(magic)
+	defer C.free(unsafe.Pointer(cmagic))
+
+	native := C.fz_open_document_with_stream(ctx, cmagic, stream)
 	if native == nil {
 		err = ErrOpenDocument
 		return
 	}
 
-	ret := C.pdf_needs_password(ctx, native)
-	if bool(int(ret) != 0) {
-		err = ErrNeedsPassword
-		C.pdf_drop_document(ctx, native)
-		C.fz_drop_context(ctx)
-		return nil, err
-	}
-
-	userCtx := newusercontext()
-	userCtx.fontCache.init(ctx, native)
-	ctx.user = pointer.Save(userCtx)
-
-	return newDocument(ctx, native), nil
+	return openDocument(ctx, native, opts.Password)
 }
 
 func NewDocumentFromFile(fileName string) (d *Document, err error) {
+	return NewDocumentFromFileWithOptions(fileName, OpenOptions{})
+}
+
+// NewDocumentFromFileWithOptions opens the named file. The document handler
+// is chosen from the file extension, so opts.Type is ignored.
+func NewDocumentFromFileWithOptions(fileName string, opts OpenOptions) (d *Document, err error) {
 	fileName, err = filepath.Abs(fileName)
 	if err != nil {
 		return
@@ -218,22 +290,40 @@ func NewDocumentFromFile(fileName string) (d *Document, err error) {
 	fname := C.CString(fileName)
 	defer C.free(unsafe.Pointer(fname))
 
-	native := C.pdf_open_document(ctx, fname)
+	native := C.fz_open_document(ctx, fname)
 	if native == nil {
 		err = ErrOpenDocument
 		return
 	}
 
-	ret := C.pdf_needs_password(ctx, native)
-	if bool(int(ret) != 0) {
-		err = ErrNeedsPassword
-		C.pdf_drop_document(ctx, native)
-		C.fz_drop_context(ctx)
-		return nil, err
+	return openDocument(ctx, native, opts.Password)
+}
+
+func openDocument(ctx *C.fz_context, native *C.fz_document, password string) (*Document, error) {
+	if C.fz_needs_password(ctx, native) != 0 {
+		err := ErrNeedsPassword
+		if password != "" {
+			pw := C.CString(password)
+			defer C.free(unsafe.Pointer(pw))
+
+			if C.fz_authenticate_password(ctx, native, pw) != 0 {
+				err = nil
+			} else {
+				err = ErrInvalidPassword
+			}
+		}
+
+		if err != nil {
+			C.fz_drop_document(ctx, native)
+			C.fz_drop_context(ctx)
+			return nil, err
+		}
 	}
 
 	userCtx := newusercontext()
-	userCtx.fontCache.init(ctx, native)
+	if pdf := C.pdf_specifics(ctx, native); pdf != nil {
+		userCtx.fontCache.init(ctx, pdf)
+	}
 	ctx.user = pointer.Save(userCtx)
 
 	return newDocument(ctx, native), nil
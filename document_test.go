@@ -64,3 +64,64 @@ func TestDocumentSplit(t *testing.T) {
 	}
 	newDoc.Save("/Users/bryan/Desktop/testoutput.pdf", fitz.DefaultWriteOptions())
 }
+
+// openSample opens testdata/sample.pdf. Its first page has text, a link to
+// https://example.com/, a text field named "name" and a ruled 2×2 table; its
+// second page has text only. The outline has an entry for each page.
+func openSample(t *testing.T) *fitz.Document {
+	t.Helper()
+
+	doc, err := fitz.NewDocumentFromFile("testdata/sample.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(doc.Close)
+	return doc
+}
+
+// reopen writes doc and opens the result, so that tests check what was
+// saved rather than the document in memory.
+func reopen(t *testing.T, doc *fitz.Document) *fitz.Document {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf, fitz.DefaultWriteOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := fitz.NewDocumentFromBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(saved.Close)
+	return saved
+}
+
+// loadPage loads page num of doc.
+func loadPage(t *testing.T, doc *fitz.Document, num int) *fitz.Page {
+	t.Helper()
+
+	pg, err := doc.LoadPage(num)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pg
+}
+
+func TestDocumentText(t *testing.T) {
+	// testdata/hyphen.pdf has one line on each of its three pages, the
+	// first ending in a word hyphenated across the page break
+	doc, err := fitz.NewDocumentFromFile("testdata/hyphen.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+
+	text, err := doc.Text(fitz.LayoutOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "A broken hyphen ends here.\n\nNext page."; text != want {
+		t.Errorf("Text() = %q, want %q", text, want)
+	}
+}
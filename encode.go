@@ -0,0 +1,150 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"context"
+	"image/jpeg"
+	"io"
+	"unsafe"
+)
+
+// ImageFormat is a file format for rendered pages.
+type ImageFormat int
+
+const (
+	ImagePNG ImageFormat = iota
+	// ImageJPEG is encoded with image/jpeg, as mupdf has no JPEG writer.
+	// Only opaque RGB and gray output without an ICC profile is supported.
+	ImageJPEG
+	// ImagePNM is PGM for gray and PPM for RGB output.
+	ImagePNM
+	ImagePAM
+	// ImagePBM is a halftoned 1-bit bitmap.
+	ImagePBM
+	ImagePSD
+	// ImagePKM is a halftoned 1-bit CMYK bitmap.
+	ImagePKM
+)
+
+// EncodeOptions controls Page.RenderTo.
+type EncodeOptions struct {
+	RenderOptions
+	// Quality is the JPEG quality, from 1 to 100. Defaults to 90.
+	Quality int
+	// ICCProfile is rendered into and embedded in PSD output instead of
+	// the default profile of the colorspace.
+	ICCProfile []byte
+}
+
+// RenderTo renders the page and encodes it straight to w with mupdf's own
+// writers. Alpha is kept by PNG, PAM and PSD; CMYK is supported by PAM and
+// PSD. PBM and PKM output is always gray and CMYK respectively.
+func (p *Page) RenderTo(w io.Writer, format ImageFormat, opts EncodeOptions) error {
+	return p.RenderToContext(context.Background(), w, format, opts)
+}
+
+// RenderToContext is like RenderTo but stops when ctx is done.
+func (p *Page) RenderToContext(ctx context.Context, w io.Writer, format ImageFormat, opts EncodeOptions) (err error) {
+	alpha := opts.Alpha
+	switch format {
+	case ImageJPEG:
+		if opts.Colorspace == ColorspaceCMYK || opts.Alpha || len(opts.ICCProfile) > 0 {
+			return ErrColorspace
+		}
+		quality := opts.Quality
+		if quality <= 0 {
+			quality = 90
+		}
+		img, err := p.RenderContext(ctx, opts.RenderOptions)
+		if err != nil {
+			return err
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case ImagePNG, ImagePNM:
+		if opts.Colorspace == ColorspaceCMYK {
+			return ErrColorspace
+		}
+		alpha = alpha && format == ImagePNG
+	case ImagePBM:
+		opts.Colorspace = ColorspaceGray
+		alpha = false
+	case ImagePKM:
+		opts.Colorspace = ColorspaceCMYK
+		alpha = false
+	case ImagePAM, ImagePSD:
+	default:
+		return ErrUnknownFormat
+	}
+
+	ctm, bbox := p.renderTransform(opts.RenderOptions)
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	defer catch(&err)
+
+	cs := opts.Colorspace.fitz(p.ctx)
+	if len(opts.ICCProfile) > 0 {
+		cs = newICCColorspace(p.ctx, opts.Colorspace, opts.ICCProfile)
+		defer C.fz_drop_colorspace(p.ctx, cs)
+	}
+
+	cookie := newCookie(ctx, opts.Progress)
+	defer cookie.close()
+
+	pixmap := renderPixmap(p.ctx, p.list, ctm, bbox, cs, alpha, opts.RenderOptions, cookie.ptr)
+	if pixmap == nil {
+		return ErrCreatePixmap
+	}
+	defer C.fz_drop_pixmap(p.ctx, pixmap)
+
+	if err := cookie.err(); err != nil {
+		return err
+	}
+
+	output, writer := newStreamOutputForWriter(p.ctx, 8192, w)
+	defer C.fz_drop_output(p.ctx, output)
+	defer writer.catch(&err)
+
+	switch format {
+	case ImagePNG:
+		C.fz_write_pixmap_as_png(p.ctx, output, pixmap)
+	case ImagePNM:
+		C.fz_write_pixmap_as_pnm(p.ctx, output, pixmap)
+	case ImagePAM:
+		C.fz_write_pixmap_as_pam(p.ctx, output, pixmap)
+	case ImagePSD:
+		C.fz_write_pixmap_as_psd(p.ctx, output, pixmap)
+	case ImagePBM:
+		bitmap := C.fz_new_bitmap_from_pixmap(p.ctx, pixmap, nil)
+		defer C.fz_drop_bitmap(p.ctx, bitmap)
+		C.fz_write_bitmap_as_pbm(p.ctx, output, bitmap)
+	case ImagePKM:
+		bitmap := C.fz_new_bitmap_from_pixmap(p.ctx, pixmap, nil)
+		defer C.fz_drop_bitmap(p.ctx, bitmap)
+		C.fz_write_bitmap_as_pkm(p.ctx, output, bitmap)
+	}
+
+	C.fz_close_output(p.ctx, output)
+	return writer.err
+}
+
+// newICCColorspace loads an ICC profile for the given kind of colorspace.
+// The caller must drop the result.
+func newICCColorspace(ctx *C.fz_context, kind OutputColorspace, profile []byte) *C.fz_colorspace {
+	typ := C.enum_fz_colorspace_type(C.FZ_COLORSPACE_RGB)
+	switch kind {
+	case ColorspaceGray:
+		typ = C.FZ_COLORSPACE_GRAY
+	case ColorspaceCMYK:
+		typ = C.FZ_COLORSPACE_CMYK
+	}
+
+	buf := C.fz_new_buffer_from_copied_data(ctx, (*C.uchar)(unsafe.Pointer(&profile[0])), C.size_t(len(profile)))
+	defer C.fz_drop_buffer(ctx, buf)
+
+	name := C.CString("ICCProfile")
+	defer C.free(unsafe.Pointer(name))
+
+	return C.fz_new_icc_colorspace(ctx, typ, 0, name, buf)
+}
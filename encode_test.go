@@ -0,0 +1,113 @@
+package fitz_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/bryanmatteson/fitz"
+)
+
+// failingWriter fails every write and counts the attempts.
+type failingWriter struct {
+	writes int
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errWriteFailed
+}
+
+func TestRenderTo(t *testing.T) {
+	pg := loadPage(t, openSample(t), 0)
+
+	// at 36 dpi the 612×792pt page is 306×396 pixels
+	render := fitz.RenderOptions{DPI: 36}
+	gray := fitz.RenderOptions{DPI: 36, Colorspace: fitz.ColorspaceGray}
+	decode := func(data []byte) (image.Image, error) { return png.Decode(bytes.NewReader(data)) }
+
+	tests := []struct {
+		name   string
+		format fitz.ImageFormat
+		opts   fitz.EncodeOptions
+		// magic is the start of the output, for formats without a decoder
+		magic  string
+		decode func([]byte) (image.Image, error)
+	}{
+		{name: "png", format: fitz.ImagePNG, opts: fitz.EncodeOptions{RenderOptions: render}, decode: decode},
+		{
+			name:   "jpeg",
+			format: fitz.ImageJPEG,
+			opts:   fitz.EncodeOptions{RenderOptions: render, Quality: 50},
+			decode: func(data []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(data)) },
+		},
+		{name: "ppm", format: fitz.ImagePNM, opts: fitz.EncodeOptions{RenderOptions: render}, magic: "P6\n306 396\n"},
+		{name: "pgm", format: fitz.ImagePNM, opts: fitz.EncodeOptions{RenderOptions: gray}, magic: "P5\n306 396\n"},
+		{name: "pam", format: fitz.ImagePAM, opts: fitz.EncodeOptions{RenderOptions: render}, magic: "P7\n"},
+		{name: "pbm", format: fitz.ImagePBM, opts: fitz.EncodeOptions{RenderOptions: render}, magic: "P4\n306 396\n"},
+		{name: "pkm", format: fitz.ImagePKM, opts: fitz.EncodeOptions{RenderOptions: render}, magic: "P7\n"},
+		{name: "psd", format: fitz.ImagePSD, opts: fitz.EncodeOptions{RenderOptions: render}, magic: "8BPS"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := pg.RenderTo(&buf, tt.format, tt.opts); err != nil {
+			t.Errorf("%s: RenderTo() = %v", tt.name, err)
+			continue
+		}
+
+		if tt.decode == nil {
+			if !bytes.HasPrefix(buf.Bytes(), []byte(tt.magic)) {
+				t.Errorf("%s: RenderTo() output starts %.20q, want %q", tt.name, buf.String(), tt.magic)
+			}
+			continue
+		}
+
+		img, err := tt.decode(buf.Bytes())
+		if err != nil {
+			t.Errorf("%s: RenderTo() output does not decode: %v", tt.name, err)
+			continue
+		}
+		if b := img.Bounds(); b.Dx() != 306 || b.Dy() != 396 {
+			t.Errorf("%s: RenderTo() image is %d×%d, want 306×396", tt.name, b.Dx(), b.Dy())
+		}
+	}
+}
+
+func TestRenderToErrors(t *testing.T) {
+	pg := loadPage(t, openSample(t), 0)
+	render := fitz.RenderOptions{DPI: 36}
+
+	tests := []struct {
+		name   string
+		format fitz.ImageFormat
+		opts   fitz.EncodeOptions
+		want   error
+	}{
+		{"jpeg with alpha", fitz.ImageJPEG, fitz.EncodeOptions{RenderOptions: fitz.RenderOptions{DPI: 36, Alpha: true}}, fitz.ErrColorspace},
+		{"cmyk png", fitz.ImagePNG, fitz.EncodeOptions{RenderOptions: fitz.RenderOptions{DPI: 36, Colorspace: fitz.ColorspaceCMYK}}, fitz.ErrColorspace},
+		{"unknown format", fitz.ImageFormat(-1), fitz.EncodeOptions{RenderOptions: render}, fitz.ErrUnknownFormat},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := pg.RenderTo(&buf, tt.format, tt.opts); err != tt.want {
+			t.Errorf("%s: RenderTo() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// the PAM output is several times the output buffer, but writing stops
+	// at the first failure
+	w := &failingWriter{}
+	if err := pg.RenderTo(w, fitz.ImagePAM, fitz.EncodeOptions{RenderOptions: render}); err != errWriteFailed {
+		t.Errorf("RenderTo() to a failing writer = %v, want %v", err, errWriteFailed)
+	}
+	if w.writes != 1 {
+		t.Errorf("RenderTo() to a failing writer made %d writes, want 1", w.writes)
+	}
+}
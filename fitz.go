@@ -31,18 +31,26 @@ func unlock_mutex(user unsafe.Pointer, lock C.int) {
 }
 
 var (
-	ErrUnknownSource = errors.New("fitz: unknown source")
-	ErrNoSuchFile    = errors.New("fitz: no such file")
-	ErrCreateContext = errors.New("fitz: cannot create context")
-	ErrOpenDocument  = errors.New("fitz: cannot open document")
-	ErrOpenMemory    = errors.New("fitz: cannot open memory")
-	ErrOpenReader    = errors.New("fitz: cannot read from reader")
-	ErrPageMissing   = errors.New("fitz: page missing")
-	ErrCreatePixmap  = errors.New("fitz: cannot create pixmap")
-	ErrPixmapSamples = errors.New("fitz: cannot get pixmap samples")
-	ErrNeedsPassword = errors.New("fitz: document needs password")
-	ErrLoadOutline   = errors.New("fitz: cannot load outline")
-	ErrInvalidPage   = errors.New("fitz: cannot load page")
+	ErrUnknownSource         = errors.New("fitz: unknown source")
+	ErrNoSuchFile            = errors.New("fitz: no such file")
+	ErrCreateContext         = errors.New("fitz: cannot create context")
+	ErrOpenDocument          = errors.New("fitz: cannot open document")
+	ErrOpenMemory            = errors.New("fitz: cannot open memory")
+	ErrOpenReader            = errors.New("fitz: cannot read from reader")
+	ErrPageMissing           = errors.New("fitz: page missing")
+	ErrCreatePixmap          = errors.New("fitz: cannot create pixmap")
+	ErrPixmapSamples         = errors.New("fitz: cannot get pixmap samples")
+	ErrNeedsPassword         = errors.New("fitz: document needs password")
+	ErrInvalidPassword       = errors.New("fitz: invalid password")
+	ErrLoadOutline           = errors.New("fitz: cannot load outline")
+	ErrInvalidPage           = errors.New("fitz: cannot load page")
+	ErrNotPDF                = errors.New("fitz: document is not a pdf")
+	ErrLinkMissing           = errors.New("fitz: link missing")
+	ErrAnnotMissing          = errors.New("fitz: annotation missing")
+	ErrUnsupportedAnnotation = errors.New("fitz: unsupported annotation type")
+	ErrFieldMissing          = errors.New("fitz: form field missing")
+	ErrUnknownFormat         = errors.New("fitz: unknown format")
+	ErrColorspace            = errors.New("fitz: unsupported colorspace")
 )
 
 //export exception_callback
@@ -72,3 +80,11 @@ func catch(err *error) {
 		}
 	}
 }
+
+// catchAs is like catch, but wraps the recovered error with sentinel so
+// callers can match it with errors.Is.
+func catchAs(err *error, sentinel error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("%w: %v", sentinel, r)
+	}
+}
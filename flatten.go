@@ -0,0 +1,209 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// FlattenOptions controls which annotations Document.Flatten bakes into the
+// page content.
+type FlattenOptions struct {
+	// Subtypes limits flattening to the named PDF annotation subtypes, e.g.
+	// "Highlight", "Stamp" or "Widget" for form fields. If empty, every
+	// annotation except links and popups is flattened.
+	Subtypes []string
+}
+
+func (o FlattenOptions) includes(subtype string) bool {
+	if len(o.Subtypes) == 0 {
+		return true
+	}
+	for _, s := range o.Subtypes {
+		if s == subtype {
+			return true
+		}
+	}
+	return false
+}
+
+// Flatten draws the appearance of annotations and form widgets into the
+// content of their pages and removes the interactive objects. Hidden
+// annotations are removed without being drawn. Flattened form fields are
+// also removed from the document's AcroForm.
+func (d *Document) Flatten(opts FlattenOptions) (err error) {
+	if d.pdf == nil {
+		return ErrNotPDF
+	}
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	defer catch(&err)
+
+	widgets := make(map[int]bool)
+	numPages := int(C.pdf_count_pages(d.ctx, d.pdf))
+
+	for i := 0; i < numPages; i++ {
+		pg := C.pdf_load_page(d.ctx, d.pdf, C.int(i))
+		changed := flattenPage(d.ctx, d.pdf, pg, opts, widgets)
+		C.fz_drop_page(d.ctx, &pg.super)
+
+		if page, ok := d.pages[i]; ok && changed {
+			// the page we edited still lists the removed widgets, so
+			// rebuild from a fresh load
+			pg = C.pdf_load_page(d.ctx, d.pdf, C.int(i))
+			page.lockedReload(&pg.super)
+			C.fz_drop_page(d.ctx, &pg.super)
+		}
+	}
+
+	if len(widgets) > 0 {
+		acroForm := C.pdf_dict_get(d.ctx, pdfCatalog(d.ctx, d.pdf), pdfName(C.PDF_ENUM_NAME_AcroForm))
+		pruneFields(d.ctx, C.pdf_dict_get(d.ctx, acroForm, pdfName(C.PDF_ENUM_NAME_Fields)), widgets)
+
+		key := C.CString("NeedAppearances")
+		C.pdf_dict_dels(d.ctx, acroForm, key)
+		C.free(unsafe.Pointer(key))
+	}
+
+	return nil
+}
+
+func flattenPage(ctx *C.fz_context, doc *C.pdf_document, pg *C.pdf_page, opts FlattenOptions, widgets map[int]bool) bool {
+	var annots, forms []*C.pdf_annot
+	for annot := C.pdf_first_annot(ctx, pg); annot != nil; annot = C.pdf_next_annot(ctx, annot) {
+		switch C.pdf_annot_type(ctx, annot) {
+		case C.PDF_ANNOT_LINK, C.PDF_ANNOT_POPUP:
+			continue
+		}
+		if opts.includes(C.GoString(C.pdf_string_from_annot_type(ctx, C.pdf_annot_type(ctx, annot)))) {
+			annots = append(annots, annot)
+		}
+	}
+	if opts.includes("Widget") {
+		for w := C.pdf_first_widget(ctx, pg); w != nil; w = C.pdf_next_widget(ctx, w) {
+			forms = append(forms, w)
+		}
+	}
+
+	if len(annots) == 0 && len(forms) == 0 {
+		return false
+	}
+
+	resources := pageResources(ctx, doc, pg)
+	xobjects := C.pdf_dict_get(ctx, resources, pdfName(C.PDF_ENUM_NAME_XObject))
+	if xobjects == nil {
+		xobjects = C.pdf_dict_put_dict(ctx, resources, pdfName(C.PDF_ENUM_NAME_XObject), 4)
+	}
+
+	var content strings.Builder
+	for _, annot := range append(annots, forms...) {
+		C.pdf_update_annot(ctx, annot)
+
+		flags := C.pdf_annot_flags(ctx, annot)
+		if flags&(C.PDF_ANNOT_IS_HIDDEN|C.PDF_ANNOT_IS_NO_VIEW) != 0 {
+			continue
+		}
+
+		ap := C.pdf_annot_ap(ctx, annot)
+		if ap == nil {
+			continue
+		}
+
+		name := fmt.Sprintf("Flat%d", int(C.pdf_to_num(ctx, annot.obj)))
+		cname := C.CString(name)
+		C.pdf_dict_puts(ctx, xobjects, cname, ap)
+		C.free(unsafe.Pointer(cname))
+
+		m := C.pdf_annot_transform(ctx, annot)
+		fmt.Fprintf(&content, "q %g %g %g %g %g %g cm /%s Do Q\n", m.a, m.b, m.c, m.d, m.e, m.f, name)
+	}
+
+	annotsArr := C.pdf_dict_get(ctx, pg.obj, pdfName(C.PDF_ENUM_NAME_Annots))
+	for _, w := range forms {
+		widgets[int(C.pdf_to_num(ctx, w.obj))] = true
+		if idx := C.pdf_array_find(ctx, annotsArr, w.obj); idx >= 0 {
+			C.pdf_array_delete(ctx, annotsArr, idx)
+		}
+	}
+	for _, annot := range annots {
+		C.pdf_delete_annot(ctx, pg, annot)
+	}
+
+	if content.Len() > 0 {
+		appendPageContent(ctx, doc, pg, content.String())
+	}
+
+	return true
+}
+
+// pageResources returns the resource dictionary of the page, copying an
+// inherited one onto the page so it can be edited locally.
+func pageResources(ctx *C.fz_context, doc *C.pdf_document, pg *C.pdf_page) *C.pdf_obj {
+	key := pdfName(C.PDF_ENUM_NAME_Resources)
+	if res := C.pdf_dict_get(ctx, pg.obj, key); res != nil {
+		return res
+	}
+
+	var res *C.pdf_obj
+	if inherited := C.pdf_dict_get_inheritable(ctx, pg.obj, key); inherited != nil {
+		res = C.pdf_copy_dict(ctx, inherited)
+	} else {
+		res = C.pdf_new_dict(ctx, doc, 4)
+	}
+	C.pdf_dict_put_drop(ctx, pg.obj, key, res)
+	return res
+}
+
+// appendPageContent draws content on top of the existing page content,
+// isolating it from any graphics state the page leaves behind.
+func appendPageContent(ctx *C.fz_context, doc *C.pdf_document, pg *C.pdf_page, content string) {
+	contents := C.pdf_page_contents(ctx, pg)
+
+	arr := C.pdf_new_array(ctx, doc, 3)
+	C.pdf_array_push_drop(ctx, arr, addContentStream(ctx, doc, "q\n"))
+	if C.pdf_is_array(ctx, contents) != 0 {
+		for i := 0; i < int(C.pdf_array_len(ctx, contents)); i++ {
+			C.pdf_array_push(ctx, arr, C.pdf_array_get(ctx, contents, C.int(i)))
+		}
+	} else if contents != nil {
+		C.pdf_array_push(ctx, arr, contents)
+	}
+	C.pdf_array_push_drop(ctx, arr, addContentStream(ctx, doc, "Q\n"+content))
+
+	C.pdf_dict_put_drop(ctx, pg.obj, pdfName(C.PDF_ENUM_NAME_Contents), arr)
+}
+
+func addContentStream(ctx *C.fz_context, doc *C.pdf_document, content string) *C.pdf_obj {
+	data := C.CString(content)
+	defer C.free(unsafe.Pointer(data))
+
+	buf := C.fz_new_buffer_from_copied_data(ctx, (*C.uchar)(unsafe.Pointer(data)), C.size_t(len(content)))
+	defer C.fz_drop_buffer(ctx, buf)
+
+	return C.pdf_add_stream(ctx, doc, buf, nil, 0)
+}
+
+// pruneFields removes flattened widgets from a field array, along with any
+// field left without kids.
+func pruneFields(ctx *C.fz_context, fields *C.pdf_obj, widgets map[int]bool) {
+	for i := int(C.pdf_array_len(ctx, fields)) - 1; i >= 0; i-- {
+		field := C.pdf_array_get(ctx, fields, C.int(i))
+		if widgets[int(C.pdf_to_num(ctx, field))] {
+			C.pdf_array_delete(ctx, fields, C.int(i))
+			continue
+		}
+
+		kids := C.pdf_dict_get(ctx, field, pdfName(C.PDF_ENUM_NAME_Kids))
+		if kids == nil {
+			continue
+		}
+
+		pruneFields(ctx, kids, widgets)
+		if C.pdf_array_len(ctx, kids) == 0 {
+			C.pdf_array_delete(ctx, fields, C.int(i))
+		}
+	}
+}
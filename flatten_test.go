@@ -0,0 +1,45 @@
+package fitz_test
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/bryanmatteson/fitz"
+)
+
+func TestFlatten(t *testing.T) {
+	doc := openSample(t)
+
+	if err := doc.SetFormField("name", "Alice"); err != nil {
+		t.Fatal(err)
+	}
+	square := &fitz.Square{BorderWidth: 1}
+	square.Rect = rectWH(100, 300, 100, 50)
+	square.Color = color.Black
+	if err := loadPage(t, doc, 0).CreateAnnotation(square); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := doc.Flatten(fitz.FlattenOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := reopen(t, doc)
+	pg := loadPage(t, saved, 0)
+
+	if fields, err := saved.FormFields(); err != nil || len(fields) != 0 {
+		t.Errorf("FormFields() after Flatten = %d fields, %v, want none", len(fields), err)
+	}
+	if annots, err := pg.Annotations(); err != nil || len(annots) != 0 {
+		t.Errorf("Annotations() after Flatten = %d annotations, %v, want none", len(annots), err)
+	}
+	if links, err := pg.Links(); err != nil || len(links) != 1 {
+		t.Errorf("Links() after Flatten = %d links, %v, want the link kept", len(links), err)
+	}
+
+	// the field value is now part of the page content
+	if text := pg.GetText(); !strings.Contains(text, "Alice") {
+		t.Errorf("GetText() after Flatten = %q, want it to contain Alice", text)
+	}
+}
@@ -0,0 +1,231 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"unsafe"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// FieldType is the kind of an interactive form field.
+type FieldType int
+
+const (
+	FieldUnknown     FieldType = C.PDF_WIDGET_TYPE_UNKNOWN
+	FieldButton      FieldType = C.PDF_WIDGET_TYPE_BUTTON
+	FieldCheckbox    FieldType = C.PDF_WIDGET_TYPE_CHECKBOX
+	FieldComboBox    FieldType = C.PDF_WIDGET_TYPE_COMBOBOX
+	FieldListBox     FieldType = C.PDF_WIDGET_TYPE_LISTBOX
+	FieldRadioButton FieldType = C.PDF_WIDGET_TYPE_RADIOBUTTON
+	FieldSignature   FieldType = C.PDF_WIDGET_TYPE_SIGNATURE
+	FieldText        FieldType = C.PDF_WIDGET_TYPE_TEXT
+)
+
+// FieldFlags are the /Ff bits of a form field.
+type FieldFlags int
+
+const (
+	FieldReadOnly    FieldFlags = C.PDF_FIELD_IS_READ_ONLY
+	FieldRequired    FieldFlags = C.PDF_FIELD_IS_REQUIRED
+	FieldNoExport    FieldFlags = C.PDF_FIELD_IS_NO_EXPORT
+	FieldMultiline   FieldFlags = C.PDF_TX_FIELD_IS_MULTILINE
+	FieldPassword    FieldFlags = C.PDF_TX_FIELD_IS_PASSWORD
+	FieldComb        FieldFlags = C.PDF_TX_FIELD_IS_COMB
+	FieldNoToggleOff FieldFlags = C.PDF_BTN_FIELD_IS_NO_TOGGLE_TO_OFF
+	FieldRadio       FieldFlags = C.PDF_BTN_FIELD_IS_RADIO
+	FieldPushButton  FieldFlags = C.PDF_BTN_FIELD_IS_PUSHBUTTON
+	FieldCombo       FieldFlags = C.PDF_CH_FIELD_IS_COMBO
+	FieldEdit        FieldFlags = C.PDF_CH_FIELD_IS_EDIT
+	FieldMultiSelect FieldFlags = C.PDF_CH_FIELD_IS_MULTI_SELECT
+)
+
+// Widget is a single on-page appearance of a form field.
+type Widget struct {
+	Page int
+	Rect gfx.Rect
+	// OnState is the export value of a checkbox or radio button widget.
+	OnState string
+}
+
+// FormField is an interactive form field and its widgets.
+type FormField struct {
+	// Name is the fully qualified field name.
+	Name  string
+	Type  FieldType
+	Value string
+	// Values holds the selected options of a multi-select list box.
+	Values []string
+	// Options lists the choices of combo and list boxes, and the export
+	// values of checkboxes and radio buttons.
+	Options []string
+	Flags   FieldFlags
+	MaxLen  int
+	Widgets []Widget
+}
+
+// FormFields returns the interactive form fields of the document in page
+// order.
+func (d *Document) FormFields() (fields []*FormField, err error) {
+	if d.pdf == nil {
+		return nil, ErrNotPDF
+	}
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	defer catch(&err)
+
+	byName := make(map[string]*FormField)
+	numPages := int(C.pdf_count_pages(d.ctx, d.pdf))
+
+	for i := 0; i < numPages; i++ {
+		pg := C.pdf_load_page(d.ctx, d.pdf, C.int(i))
+
+		for w := C.pdf_first_widget(d.ctx, pg); w != nil; w = C.pdf_next_widget(d.ctx, w) {
+			name := fieldName(d.ctx, w.obj)
+
+			field, ok := byName[name]
+			if !ok {
+				field = newFormField(d.ctx, w, name)
+				byName[name] = field
+				fields = append(fields, field)
+			}
+
+			widget := Widget{Page: i, Rect: rectFromFitz(C.pdf_bound_widget(d.ctx, w))}
+			if field.Type == FieldCheckbox || field.Type == FieldRadioButton {
+				widget.OnState = C.GoString(C.pdf_to_name(d.ctx, C.pdf_button_field_on_state(d.ctx, w.obj)))
+				field.Options = appendUnique(field.Options, widget.OnState)
+			}
+			field.Widgets = append(field.Widgets, widget)
+		}
+
+		C.fz_drop_page(d.ctx, &pg.super)
+	}
+
+	return fields, nil
+}
+
+// SetFormField sets the value of the named field and regenerates the
+// appearance of its widgets. Text and single choice fields take one value,
+// multi-select list boxes take any number. Checkboxes and radio buttons take
+// the export value of the widget to turn on, or "Off" to clear them.
+func (d *Document) SetFormField(name string, values ...string) (err error) {
+	if d.pdf == nil {
+		return ErrNotPDF
+	}
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	defer catch(&err)
+
+	value := ""
+	if len(values) > 0 {
+		value = values[0]
+	}
+
+	cvalue := C.CString(value)
+	defer C.free(unsafe.Pointer(cvalue))
+
+	found := false
+	numPages := int(C.pdf_count_pages(d.ctx, d.pdf))
+
+	for i := 0; i < numPages; i++ {
+		pg := C.pdf_load_page(d.ctx, d.pdf, C.int(i))
+		changed := false
+
+		for w := C.pdf_first_widget(d.ctx, pg); w != nil; w = C.pdf_next_widget(d.ctx, w) {
+			if fieldName(d.ctx, w.obj) != name {
+				continue
+			}
+			found, changed = true, true
+
+			switch FieldType(C.pdf_widget_type(d.ctx, w)) {
+			case FieldText:
+				C.pdf_set_text_field_value(d.ctx, w, cvalue)
+
+			case FieldComboBox, FieldListBox:
+				if C.pdf_choice_widget_is_multiselect(d.ctx, w) != 0 {
+					setChoiceValues(d.ctx, w, values)
+				} else {
+					C.pdf_set_choice_field_value(d.ctx, w, cvalue)
+				}
+
+			case FieldCheckbox, FieldRadioButton:
+				on := C.GoString(C.pdf_to_name(d.ctx, C.pdf_button_field_on_state(d.ctx, w.obj)))
+				state := C.GoString(C.pdf_dict_get_name(d.ctx, w.obj, pdfName(C.PDF_ENUM_NAME_AS)))
+				if (state == on) != (value == on) {
+					C.pdf_toggle_widget(d.ctx, w)
+				}
+			}
+		}
+
+		if changed {
+			C.pdf_update_page(d.ctx, pg)
+			if page, ok := d.pages[i]; ok {
+				page.lockedReload(&pg.super)
+			}
+		}
+
+		C.fz_drop_page(d.ctx, &pg.super)
+	}
+
+	if !found {
+		return ErrFieldMissing
+	}
+
+	return nil
+}
+
+func newFormField(ctx *C.fz_context, w *C.pdf_widget, name string) *FormField {
+	field := &FormField{
+		Name:  name,
+		Type:  FieldType(C.pdf_widget_type(ctx, w)),
+		Value: C.GoString(C.pdf_field_value(ctx, w.obj)),
+		Flags: FieldFlags(C.pdf_field_flags(ctx, w.obj)),
+	}
+
+	switch field.Type {
+	case FieldText:
+		field.MaxLen = int(C.pdf_text_widget_max_len(ctx, w))
+
+	case FieldComboBox, FieldListBox:
+		n := int(C.pdf_choice_field_option_count(ctx, w.obj))
+		for i := 0; i < n; i++ {
+			field.Options = append(field.Options, C.GoString(C.pdf_choice_field_option(ctx, w.obj, 0, C.int(i))))
+		}
+
+		if n := int(C.pdf_choice_widget_value(ctx, w, nil)); n > 0 {
+			opts := make([]*C.char, n)
+			C.pdf_choice_widget_value(ctx, w, &opts[0])
+			for _, opt := range opts {
+				field.Values = append(field.Values, C.GoString(opt))
+			}
+		}
+	}
+
+	return field
+}
+
+func setChoiceValues(ctx *C.fz_context, w *C.pdf_widget, values []string) {
+	opts := make([]*C.char, len(values)+1)
+	for i, v := range values {
+		opts[i] = C.CString(v)
+		defer C.free(unsafe.Pointer(opts[i]))
+	}
+	C.pdf_choice_widget_set_value(ctx, w, C.int(len(values)), &opts[0])
+}
+
+func fieldName(ctx *C.fz_context, obj *C.pdf_obj) string {
+	cname := C.pdf_field_name(ctx, obj)
+	defer C.fz_free(ctx, unsafe.Pointer(cname))
+	return C.GoString(cname)
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,51 @@
+package fitz_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bryanmatteson/fitz"
+)
+
+func TestFormFields(t *testing.T) {
+	fields, err := openSample(t).FormFields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 1 {
+		t.Fatalf("FormFields() = %d fields, want 1", len(fields))
+	}
+
+	field := fields[0]
+	if field.Name != "name" || field.Type != fitz.FieldText || field.Value != "" {
+		t.Errorf("FormFields() = %+v, want an empty text field named name", field)
+	}
+	if len(field.Widgets) != 1 || field.Widgets[0].Page != 0 || !rectNear(field.Widgets[0].Rect, rectWH(72, 372, 200, 20)) {
+		t.Errorf("FormFields() widgets = %+v, want one on the first page at 72,372 200×20", field.Widgets)
+	}
+}
+
+func TestSetFormField(t *testing.T) {
+	doc := openSample(t)
+
+	if err := doc.SetFormField("name", "Alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.SetFormField("missing", "Bob"); err != fitz.ErrFieldMissing {
+		t.Errorf("SetFormField() of a missing field = %v, want %v", err, fitz.ErrFieldMissing)
+	}
+
+	saved := reopen(t, doc)
+	fields, err := saved.FormFields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 1 || fields[0].Value != "Alice" {
+		t.Errorf("FormFields() after SetFormField = %+v, want name set to Alice", fields)
+	}
+
+	// the appearance of the widget shows the new value
+	if text := loadPage(t, saved, 0).GetText(); !strings.Contains(text, "Alice") {
+		t.Errorf("GetText() after SetFormField = %q, want it to contain Alice", text)
+	}
+}
@@ -0,0 +1,293 @@
+package fitz
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// HiddenReason explains why a text span cannot be seen.
+type HiddenReason int
+
+const (
+	// HiddenInvisible text is drawn with the invisible render mode, as in
+	// the text layer of an OCRed scan.
+	HiddenInvisible HiddenReason = iota + 1
+	// HiddenTransparent text is drawn fully transparent.
+	HiddenTransparent
+	// HiddenOffPage text lies outside the page.
+	HiddenOffPage
+	// HiddenClipped text is entirely clipped away.
+	HiddenClipped
+	// HiddenTiny text is too small to read.
+	HiddenTiny
+	// HiddenCovered text is painted over by an image or an opaque shape.
+	HiddenCovered
+	// HiddenNoContrast text has the same color as what lies beneath it,
+	// such as white text on a white page.
+	HiddenNoContrast
+)
+
+// HiddenSpan is a text span that a reader of the rendered page cannot see.
+type HiddenSpan struct {
+	Span   *TextSpan
+	Text   string
+	Bounds gfx.Rect
+	Reason HiddenReason
+}
+
+// minReadableSize is the smallest font size, in points, considered
+// readable.
+const minReadableSize = 1.0
+
+// HiddenTextDevice is a Device that tracks the clip stack and painted
+// regions of a page to find text hidden from a reader.
+type HiddenTextDevice struct {
+	BaseDevice
+	page   gfx.Rect
+	clips  []gfx.Rect
+	alphas []float64
+	spans  []hiddenCandidate
+	paints []paintedRegion
+	// masks counts the soft masks being defined; what is drawn into them
+	// is never seen
+	masks int
+}
+
+type hiddenCandidate struct {
+	span   *TextSpan
+	bounds gfx.Rect
+	clip   gfx.Rect
+	alpha  float64
+	order  int
+}
+
+// paintedRegion is an area painted over the page. Opaque regions hide what
+// lies beneath, and solid regions have a known color.
+type paintedRegion struct {
+	bounds gfx.Rect
+	color  color.Color
+	opaque bool
+	order  int
+}
+
+// NewHiddenTextDevice creates a device for a page with the given bounds.
+func NewHiddenTextDevice(page gfx.Rect) *HiddenTextDevice {
+	return &HiddenTextDevice{page: page, clips: []gfx.Rect{page}, alphas: []float64{1}}
+}
+
+func (dev *HiddenTextDevice) clip() gfx.Rect      { return dev.clips[len(dev.clips)-1] }
+func (dev *HiddenTextDevice) groupAlpha() float64 { return dev.alphas[len(dev.alphas)-1] }
+
+func (dev *HiddenTextDevice) pushClip(r gfx.Rect) {
+	dev.clips = append(dev.clips, dev.clip().Intersection(r))
+}
+
+func (dev *HiddenTextDevice) paint(r gfx.Rect, col color.Color, alpha float64) {
+	if dev.masks > 0 {
+		return
+	}
+
+	r = dev.clip().Intersection(r)
+	if r.IsEmpty() {
+		return
+	}
+
+	alpha *= dev.groupAlpha()
+	if col != nil {
+		_, _, _, a := col.RGBA()
+		alpha *= float64(a) / 0xffff
+	}
+
+	dev.paints = append(dev.paints, paintedRegion{bounds: r, color: col, opaque: alpha >= 1, order: len(dev.spans) + len(dev.paints)})
+}
+
+func (dev *HiddenTextDevice) addText(text *Text, ctm gfx.Matrix) {
+	if dev.masks > 0 {
+		return
+	}
+
+	for _, span := range text.Spans {
+		if len(span.Letters) == 0 {
+			continue
+		}
+
+		dev.spans = append(dev.spans, hiddenCandidate{
+			span:   span,
+			bounds: spanBounds(span, ctm),
+			clip:   dev.clip(),
+			alpha:  span.Alpha * dev.groupAlpha(),
+			order:  len(dev.spans) + len(dev.paints),
+		})
+	}
+}
+
+func (dev *HiddenTextDevice) FillPath(path *gfx.Path, fillRule gfx.FillRule, ctm gfx.Matrix, fillColor color.Color) {
+	dev.paint(ctm.TransformRect(path.Bounds()), fillColor, 1)
+}
+
+// StrokePath is ignored: a stroke only paints along the path, so its bounds
+// neither cover text nor give the color beneath it.
+func (dev *HiddenTextDevice) StrokePath(path *gfx.Path, stroke *gfx.Stroke, ctm gfx.Matrix, strokeColor color.Color) {
+}
+
+func (dev *HiddenTextDevice) FillShade(shade *gfx.Shader, ctm gfx.Matrix, alpha float64) {
+	dev.paint(dev.clip(), nil, alpha)
+}
+
+func (dev *HiddenTextDevice) FillImage(img image.Image, ctm gfx.Matrix, alpha float64) {
+	dev.paint(ctm.TransformRect(unitRect), nil, alpha)
+}
+
+// FillImageMask is ignored for the same reason as StrokePath: only the
+// pixels set in the mask are painted.
+func (dev *HiddenTextDevice) FillImageMask(img image.Image, ctm gfx.Matrix, fillColor color.Color) {}
+
+func (dev *HiddenTextDevice) ClipPath(path *gfx.Path, fillRule gfx.FillRule, ctm gfx.Matrix, scissor gfx.Rect) {
+	dev.pushClip(ctm.TransformRect(path.Bounds()))
+}
+
+func (dev *HiddenTextDevice) ClipStrokePath(path *gfx.Path, stroke *gfx.Stroke, ctm gfx.Matrix, scissor gfx.Rect) {
+	w := stroke.LineWidth / 2
+	dev.pushClip(ctm.TransformRect(path.Bounds().Expanded(gfx.MakePoint(w, w))))
+}
+
+func (dev *HiddenTextDevice) ClipImageMask(img image.Image, ctm gfx.Matrix, scissor gfx.Rect) {
+	dev.pushClip(ctm.TransformRect(unitRect))
+}
+
+func (dev *HiddenTextDevice) FillText(text *Text, ctm gfx.Matrix, fillColor color.Color) {
+	dev.addText(text, ctm)
+}
+
+func (dev *HiddenTextDevice) StrokeText(text *Text, stroke *gfx.Stroke, ctm gfx.Matrix, strokeColor color.Color) {
+	dev.addText(text, ctm)
+}
+
+func (dev *HiddenTextDevice) IgnoreText(text *Text, ctm gfx.Matrix) {
+	dev.addText(text, ctm)
+}
+
+func (dev *HiddenTextDevice) ClipText(text *Text, ctm gfx.Matrix, scissor gfx.Rect) {
+	dev.pushClip(textBounds(text, ctm))
+}
+
+func (dev *HiddenTextDevice) ClipStrokeText(text *Text, stroke *gfx.Stroke, ctm gfx.Matrix, scissor gfx.Rect) {
+	dev.pushClip(textBounds(text, ctm))
+}
+
+func (dev *HiddenTextDevice) BeginMask(rect gfx.Rect, maskColor color.Color, luminosity int) {
+	dev.pushClip(rect)
+	dev.masks++
+}
+
+func (dev *HiddenTextDevice) EndMask() {
+	if dev.masks > 0 {
+		dev.masks--
+	}
+}
+
+func (dev *HiddenTextDevice) PopClip() {
+	if len(dev.clips) > 1 {
+		dev.clips = dev.clips[:len(dev.clips)-1]
+	}
+}
+
+func (dev *HiddenTextDevice) BeginGroup(rect gfx.Rect, cs *gfx.Colorspace, isolated bool, knockout bool, blendmode gfx.BlendMode, alpha float64) {
+	dev.alphas = append(dev.alphas, dev.groupAlpha()*alpha)
+}
+
+func (dev *HiddenTextDevice) EndGroup() {
+	if len(dev.alphas) > 1 {
+		dev.alphas = dev.alphas[:len(dev.alphas)-1]
+	}
+}
+
+// HiddenText returns the spans collected so far that a reader cannot see.
+func (dev *HiddenTextDevice) HiddenText() []HiddenSpan {
+	var hidden []HiddenSpan
+	for _, c := range dev.spans {
+		if reason := dev.reason(c); reason != 0 {
+			hidden = append(hidden, HiddenSpan{Span: c.span, Text: c.span.String(), Bounds: c.bounds, Reason: reason})
+		}
+	}
+	return hidden
+}
+
+func (dev *HiddenTextDevice) reason(c hiddenCandidate) HiddenReason {
+	switch {
+	case c.span.RenderMode == RenderInvisible:
+		return HiddenInvisible
+	case c.span.RenderMode == RenderClip || c.span.RenderMode == RenderClipStroke:
+		return 0
+	case c.alpha <= 0:
+		return HiddenTransparent
+	case dev.page.Intersection(c.bounds).IsEmpty():
+		return HiddenOffPage
+	case c.clip.Intersection(c.bounds).IsEmpty():
+		return HiddenClipped
+	case c.span.Size > 0 && c.span.Size < minReadableSize:
+		return HiddenTiny
+	}
+
+	background := color.Color(color.White)
+	for _, p := range dev.paints {
+		if p.order > c.order {
+			if p.opaque && containsRect(p.bounds, c.bounds) {
+				return HiddenCovered
+			}
+			continue
+		}
+		if containsRect(p.bounds, c.bounds) && p.opaque {
+			background = p.color
+		}
+	}
+
+	if background != nil && c.span.Color != nil && sameColor(background, c.span.Color) {
+		return HiddenNoContrast
+	}
+	return 0
+}
+
+// HiddenText reports every text span on the page that is invisible to a
+// reader, and why.
+func (p *Page) HiddenText() ([]HiddenSpan, error) {
+	dev := NewHiddenTextDevice(p.Bounds())
+	if err := p.RunDevice(dev); err != nil {
+		return nil, err
+	}
+	return dev.HiddenText(), nil
+}
+
+var unitRect = gfx.Rect{X: gfx.Interval{Min: 0, Max: 1}, Y: gfx.Interval{Min: 0, Max: 1}}
+
+func textBounds(text *Text, ctm gfx.Matrix) gfx.Rect {
+	var bounds gfx.Rect
+	for _, span := range text.Spans {
+		bounds = unionRects(bounds, spanBounds(span, ctm))
+	}
+	return bounds
+}
+
+func spanBounds(span *TextSpan, ctm gfx.Matrix) gfx.Rect {
+	var bounds gfx.Rect
+	for _, letter := range span.Letters {
+		bounds = unionRects(bounds, quadBounds(transformQuad(ctm, letter.Quad)))
+	}
+	return bounds
+}
+
+func containsRect(outer, inner gfx.Rect) bool {
+	return outer.X.Min <= inner.X.Min && outer.X.Max >= inner.X.Max &&
+		outer.Y.Min <= inner.Y.Min && outer.Y.Max >= inner.Y.Max
+}
+
+// sameColor reports whether two colors are indistinguishable to a reader.
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, _ := a.RGBA()
+	br, bg, bb, _ := b.RGBA()
+	d := math.Abs(float64(ar)-float64(br)) + math.Abs(float64(ag)-float64(bg)) + math.Abs(float64(ab)-float64(bb))
+	return d/0xffff < 0.05
+}
@@ -0,0 +1,735 @@
+package fitz
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// LayoutOptions tune the heuristics of the layout analyzer. Distances are
+// fractions of the font size; zero values select the defaults.
+type LayoutOptions struct {
+	// WordGap is the smallest gap between glyphs that separates words.
+	// Defaults to 0.15.
+	WordGap float64
+	// LineGap is the largest gap between glyphs of the same line. Larger
+	// gaps, such as column gutters, split the line. Defaults to 2.
+	LineGap float64
+	// ParagraphGap is the largest gap between lines of the same paragraph.
+	// Defaults to 0.8.
+	ParagraphGap float64
+}
+
+func (o LayoutOptions) withDefaults() LayoutOptions {
+	if o.WordGap <= 0 {
+		o.WordGap = 0.15
+	}
+	if o.LineGap <= 0 {
+		o.LineGap = 2
+	}
+	if o.ParagraphGap <= 0 {
+		o.ParagraphGap = 0.8
+	}
+	return o
+}
+
+// Layout is the text of a page grouped into columns, paragraphs, lines and
+// words, in reading order.
+type Layout struct {
+	Columns []*Column
+}
+
+// Column is a region of the page read as a unit.
+type Column struct {
+	Bounds     gfx.Rect
+	Paragraphs []*Paragraph
+}
+
+// Paragraph is a block of consecutive lines.
+type Paragraph struct {
+	Bounds gfx.Rect
+	Lines  []*LayoutLine
+}
+
+// LayoutLine is a row of words, or a column of words for vertical text. Words
+// are in logical order, so right-to-left lines start at the right.
+type LayoutLine struct {
+	Bounds   gfx.Rect
+	Vertical bool
+	RTL      bool
+	Words    []*Word
+}
+
+// Word is a run of glyphs without whitespace or a significant gap. Quad
+// spans the glyphs along the baseline, Size is the largest glyph size and
+// Font and Color are those of the first glyph.
+type Word struct {
+	Text   string
+	Bounds gfx.Rect
+	Quad   gfx.Quad
+	Font   gfx.Font
+	Size   float64
+	Color  color.Color
+	// Line and Block index the word's line and paragraph in reading order
+	// across the page. They are set by Page.Words.
+	Line  int
+	Block int
+}
+
+// String returns the text in reading order, with paragraphs separated by
+// blank lines and hyphenated line breaks joined.
+func (l *Layout) String() string {
+	var paras []string
+	for _, col := range l.Columns {
+		for _, para := range col.Paragraphs {
+			paras = append(paras, para.String())
+		}
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// String returns the lines of the paragraph joined into one, removing the
+// hyphen from words broken across lines.
+func (p *Paragraph) String() string {
+	var builder strings.Builder
+	for i, line := range p.Lines {
+		text := line.String()
+		if i > 0 {
+			prev := builder.String()
+			if isHyphenated(prev, text) {
+				trimmed := strings.TrimRightFunc(prev, isHyphen)
+				builder.Reset()
+				builder.WriteString(trimmed)
+			} else {
+				builder.WriteByte(' ')
+			}
+		}
+		builder.WriteString(text)
+	}
+	return builder.String()
+}
+
+func (l *LayoutLine) String() string {
+	words := make([]string, len(l.Words))
+	for i, w := range l.Words {
+		words[i] = w.Text
+	}
+	return strings.Join(words, " ")
+}
+
+// LayoutDevice is a Device that collects the text drawn on a page for
+// layout analysis. Filled, stroked and invisible text are all collected.
+type LayoutDevice struct {
+	BaseDevice
+	glyphs []layoutGlyph
+}
+
+func NewLayoutDevice() *LayoutDevice {
+	return &LayoutDevice{}
+}
+
+func (dev *LayoutDevice) FillText(text *Text, ctm gfx.Matrix, fillColor color.Color) {
+	dev.addText(text, ctm, fillColor)
+}
+
+func (dev *LayoutDevice) StrokeText(text *Text, stroke *gfx.Stroke, ctm gfx.Matrix, strokeColor color.Color) {
+	dev.addText(text, ctm, strokeColor)
+}
+
+func (dev *LayoutDevice) IgnoreText(text *Text, ctm gfx.Matrix) {
+	dev.addText(text, ctm, nil)
+}
+
+// Layout analyzes the text collected so far.
+func (dev *LayoutDevice) Layout(opts LayoutOptions) *Layout {
+	return analyzeLayout(dev.glyphs, opts.withDefaults())
+}
+
+// Layout analyzes the text of the page and returns it in reading order.
+func (p *Page) Layout(opts LayoutOptions) (*Layout, error) {
+	dev := NewLayoutDevice()
+	if err := p.RunDevice(dev); err != nil {
+		return nil, err
+	}
+	return dev.Layout(opts), nil
+}
+
+// Text analyzes the layout of every page and returns the text of the
+// document in reading order. Pages are separated like paragraphs, except
+// that a word hyphenated across a page break is joined.
+func (d *Document) Text(opts LayoutOptions) (string, error) {
+	var pages []string
+	for i, n := 0, d.NumPages(); i < n; i++ {
+		page, err := d.LoadPage(i)
+		if err != nil {
+			return "", err
+		}
+		layout, err := page.Layout(opts)
+		if err != nil {
+			return "", err
+		}
+		pages = append(pages, layout.String())
+	}
+	return joinPages(pages), nil
+}
+
+// Words returns the words of the page in reading order.
+func (p *Page) Words(opts LayoutOptions) ([]*Word, error) {
+	layout, err := p.Layout(opts)
+	if err != nil {
+		return nil, err
+	}
+	return layout.Words(), nil
+}
+
+// Words returns the words of the layout in reading order with their line
+// and block indices set.
+func (l *Layout) Words() []*Word {
+	var words []*Word
+	line, block := 0, 0
+	for _, col := range l.Columns {
+		for _, para := range col.Paragraphs {
+			for _, ln := range para.Lines {
+				for _, w := range ln.Words {
+					w.Line, w.Block = line, block
+					words = append(words, w)
+				}
+				line++
+			}
+			block++
+		}
+	}
+	return words
+}
+
+// layoutGlyph is a letter in page space.
+type layoutGlyph struct {
+	r        rune
+	quad     gfx.Quad
+	bounds   gfx.Rect
+	size     float64
+	vertical bool
+	span     *TextSpan
+	color    color.Color
+}
+
+func (dev *LayoutDevice) addText(text *Text, ctm gfx.Matrix, col color.Color) {
+	for _, span := range text.Spans {
+		for _, letter := range span.Letters {
+			q := transformQuad(ctm, letter.Quad)
+
+			size := distance(q.BottomLeft, q.TopLeft)
+			if span.WMode == WModeVertical {
+				size = distance(q.BottomLeft, q.BottomRight)
+			}
+
+			dev.glyphs = append(dev.glyphs, layoutGlyph{
+				r:        letter.Rune,
+				quad:     q,
+				bounds:   quadBounds(q),
+				size:     size,
+				vertical: span.WMode == WModeVertical,
+				span:     span,
+				color:    col,
+			})
+		}
+	}
+}
+
+// frame maps page space rectangles into a frame where text flows along X
+// and lines stack along Y. Vertical text flows down and stacks right to
+// left.
+func frame(r gfx.Rect, vertical bool) gfx.Rect {
+	if !vertical {
+		return r
+	}
+	return gfx.Rect{X: r.Y, Y: gfx.Interval{Min: -r.X.Max, Max: -r.X.Min}}
+}
+
+type lineGroup struct {
+	glyphs   []layoutGlyph
+	bounds   gfx.Rect // in frame space
+	size     float64
+	vertical bool
+}
+
+func analyzeLayout(glyphs []layoutGlyph, opts LayoutOptions) *Layout {
+	lines := buildLines(glyphs, opts)
+	paras := buildParagraphs(lines, opts)
+
+	layout := &Layout{}
+	for _, group := range xyCut(paras) {
+		col := &Column{Paragraphs: group}
+		for _, para := range group {
+			col.Bounds = unionRects(col.Bounds, para.Bounds)
+		}
+		layout.Columns = append(layout.Columns, col)
+	}
+	return layout
+}
+
+// buildLines groups glyphs that share a baseline, splitting at gaps wider
+// than opts.LineGap so that text in neighbouring columns stays apart.
+func buildLines(glyphs []layoutGlyph, opts LayoutOptions) []*lineGroup {
+	var lines []*lineGroup
+
+	for _, g := range glyphs {
+		if unicode.IsSpace(g.r) && len(lines) == 0 {
+			continue
+		}
+
+		fr := frame(g.bounds, g.vertical)
+		var line *lineGroup
+		for i := len(lines) - 1; i >= 0; i-- {
+			if lines[i].accepts(g, fr, opts) {
+				line = lines[i]
+				break
+			}
+		}
+
+		if line == nil {
+			line = &lineGroup{vertical: g.vertical, bounds: fr, size: g.size}
+			lines = append(lines, line)
+		}
+
+		line.glyphs = append(line.glyphs, g)
+		line.bounds = unionRects(line.bounds, fr)
+		line.size = math.Max(line.size, g.size)
+	}
+
+	for _, line := range lines {
+		vertical := line.vertical
+		sort.SliceStable(line.glyphs, func(i, j int) bool {
+			return frame(line.glyphs[i].bounds, vertical).X.Min < frame(line.glyphs[j].bounds, vertical).X.Min
+		})
+	}
+
+	return lines
+}
+
+func (l *lineGroup) accepts(g layoutGlyph, fr gfx.Rect, opts LayoutOptions) bool {
+	if l.vertical != g.vertical {
+		return false
+	}
+
+	size := math.Max(l.size, g.size)
+	if size <= 0 {
+		return false
+	}
+
+	// the glyph must sit on the same baseline, within half a line
+	centre := (fr.Y.Min + fr.Y.Max) / 2
+	lineCentre := (l.bounds.Y.Min + l.bounds.Y.Max) / 2
+	if math.Abs(centre-lineCentre) > size/2 {
+		return false
+	}
+
+	gap := math.Max(fr.X.Min-l.bounds.X.Max, l.bounds.X.Min-fr.X.Max)
+	return gap <= opts.LineGap*size
+}
+
+// words splits the line into words at whitespace and at gaps wider than
+// opts.WordGap beyond the usual letter spacing of the line, and orders them
+// logically. The gap test finds word breaks in PDFs that draw no spaces.
+func (l *lineGroup) words(opts LayoutOptions) (words []*Word, rtl bool) {
+	var current []layoutGlyph
+	prevEnd := math.Inf(-1)
+	spacing := l.letterSpacing()
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, newWord(current))
+		}
+		current = nil
+	}
+
+	rtlCount, ltrCount := 0, 0
+	for _, g := range l.glyphs {
+		fr := frame(g.bounds, l.vertical)
+		if unicode.IsSpace(g.r) {
+			flush()
+			prevEnd = fr.X.Max
+			continue
+		}
+
+		if fr.X.Min-prevEnd > spacing+opts.WordGap*math.Max(g.size, 1) {
+			flush()
+		}
+
+		switch {
+		case isRTL(g.r):
+			rtlCount++
+		case unicode.IsLetter(g.r):
+			ltrCount++
+		}
+
+		current = append(current, g)
+		prevEnd = fr.X.Max
+	}
+	flush()
+
+	if l.vertical || rtlCount <= ltrCount {
+		return words, false
+	}
+
+	reverseWords(words)
+	for _, w := range words {
+		if containsRTL(w.Text) {
+			w.Text = reverseString(w.Text)
+		}
+	}
+	// keep runs of left-to-right words in their order. Runs of numbers
+	// alone have no direction of their own and read right to left with
+	// the text around them.
+	for i := 0; i < len(words); {
+		j := i
+		ltr := false
+		for j < len(words) && !containsRTL(words[j].Text) {
+			ltr = ltr || containsLTR(words[j].Text)
+			j++
+		}
+		if j > i {
+			if ltr {
+				reverseWords(words[i:j])
+			}
+			i = j
+		} else {
+			i++
+		}
+	}
+
+	return words, true
+}
+
+// letterSpacing returns the median gap between adjacent non-space glyphs of
+// the line, or zero if glyphs typically touch or overlap.
+func (l *lineGroup) letterSpacing() float64 {
+	var gaps []float64
+	for i := 1; i < len(l.glyphs); i++ {
+		a, b := l.glyphs[i-1], l.glyphs[i]
+		if unicode.IsSpace(a.r) || unicode.IsSpace(b.r) {
+			continue
+		}
+		gaps = append(gaps, frame(b.bounds, l.vertical).X.Min-frame(a.bounds, l.vertical).X.Max)
+	}
+	if len(gaps) == 0 {
+		return 0
+	}
+
+	sort.Float64s(gaps)
+	return math.Max(gaps[len(gaps)/2], 0)
+}
+
+func newWord(glyphs []layoutGlyph) *Word {
+	first := glyphs[0]
+	w := &Word{Quad: unionQuad(glyphs), Color: first.color}
+	if first.span != nil {
+		w.Font = first.span.Font
+	}
+
+	runes := make([]rune, len(glyphs))
+	for i, g := range glyphs {
+		runes[i] = g.r
+		w.Bounds = unionRects(w.Bounds, g.bounds)
+		w.Size = math.Max(w.Size, g.size)
+	}
+	w.Text = string(runes)
+
+	return w
+}
+
+// unionQuad returns the smallest quad aligned with the line direction of the
+// first glyph that covers all the glyphs. Its bottom edge runs along the
+// line, so it starts at the left of horizontal words, whatever the order of
+// their glyphs, and at the top of vertical ones.
+func unionQuad(glyphs []layoutGlyph) gfx.Quad {
+	first := glyphs[0]
+
+	// d is the line direction and n points up from it
+	from, to, fallback := first.quad.BottomLeft, first.quad.BottomRight, gfx.Point{X: 1}
+	if first.vertical {
+		from, to, fallback = first.quad.TopLeft, first.quad.BottomLeft, gfx.Point{Y: 1}
+	}
+	d := gfx.Point{X: to.X - from.X, Y: to.Y - from.Y}
+	if l := math.Hypot(d.X, d.Y); l > 0 {
+		d = gfx.Point{X: d.X / l, Y: d.Y / l}
+	} else {
+		d = fallback
+	}
+	n := gfx.Point{X: d.Y, Y: -d.X}
+
+	s0, s1 := math.Inf(1), math.Inf(-1)
+	t0, t1 := math.Inf(1), math.Inf(-1)
+	for _, g := range glyphs {
+		for _, p := range []gfx.Point{g.quad.BottomLeft, g.quad.TopLeft, g.quad.BottomRight, g.quad.TopRight} {
+			s, t := p.X*d.X+p.Y*d.Y, p.X*n.X+p.Y*n.Y
+			s0, s1 = math.Min(s0, s), math.Max(s1, s)
+			t0, t1 = math.Min(t0, t), math.Max(t1, t)
+		}
+	}
+
+	at := func(s, t float64) gfx.Point {
+		return gfx.Point{X: s*d.X + t*n.X, Y: s*d.Y + t*n.Y}
+	}
+	return gfx.Quad{BottomLeft: at(s0, t0), TopLeft: at(s0, t1), BottomRight: at(s1, t0), TopRight: at(s1, t1)}
+}
+
+type paraGroup struct {
+	lines    []*lineGroup
+	bounds   gfx.Rect // in frame space
+	vertical bool
+}
+
+// buildParagraphs stacks lines of similar size that overlap along the text
+// direction and follow each other closely.
+func buildParagraphs(lines []*lineGroup, opts LayoutOptions) []*Paragraph {
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].bounds.Y.Min < lines[j].bounds.Y.Min
+	})
+
+	var paras []*paraGroup
+	for _, line := range lines {
+		var para *paraGroup
+		for _, p := range paras {
+			last := p.lines[len(p.lines)-1]
+			if p.vertical != line.vertical {
+				continue
+			}
+			if math.Max(last.size, line.size) > 1.5*math.Min(last.size, line.size) {
+				continue
+			}
+			if overlap(last.bounds.X, line.bounds.X) <= 0 {
+				continue
+			}
+			gap := line.bounds.Y.Min - last.bounds.Y.Max
+			if gap > opts.ParagraphGap*line.size || gap < -line.size/2 {
+				continue
+			}
+			para = p
+		}
+
+		if para == nil {
+			para = &paraGroup{vertical: line.vertical, bounds: line.bounds}
+			paras = append(paras, para)
+		}
+		para.lines = append(para.lines, line)
+		para.bounds = unionRects(para.bounds, line.bounds)
+	}
+
+	out := make([]*Paragraph, 0, len(paras))
+	for _, p := range paras {
+		para := &Paragraph{}
+		for _, l := range p.lines {
+			words, rtl := l.words(opts)
+			if len(words) == 0 {
+				continue
+			}
+
+			line := &LayoutLine{Vertical: l.vertical, RTL: rtl, Words: words}
+			for _, w := range words {
+				line.Bounds = unionRects(line.Bounds, w.Bounds)
+			}
+			para.Lines = append(para.Lines, line)
+			para.Bounds = unionRects(para.Bounds, line.Bounds)
+		}
+		if len(para.Lines) > 0 {
+			out = append(out, para)
+		}
+	}
+
+	return out
+}
+
+// xyCut orders paragraphs by recursively splitting them along the widest
+// empty band, horizontal or vertical. Each region that cannot be split
+// further is a column. Vertical splits are read left to right unless the
+// text on both sides is mostly right-to-left or vertical.
+func xyCut(paras []*Paragraph) [][]*Paragraph {
+	if len(paras) <= 1 {
+		if len(paras) == 0 {
+			return nil
+		}
+		return [][]*Paragraph{paras}
+	}
+
+	hPos, hGap := widestGap(paras, func(r gfx.Rect) gfx.Interval { return r.Y })
+	vPos, vGap := widestGap(paras, func(r gfx.Rect) gfx.Interval { return r.X })
+
+	switch {
+	case hGap > 0 && hGap >= vGap:
+		top, bottom := splitParas(paras, hPos, func(r gfx.Rect) gfx.Interval { return r.Y })
+		return append(xyCut(top), xyCut(bottom)...)
+
+	case vGap > 0:
+		left, right := splitParas(paras, vPos, func(r gfx.Rect) gfx.Interval { return r.X })
+		if rightToLeft(left) && rightToLeft(right) {
+			left, right = right, left
+		}
+		return append(xyCut(left), xyCut(right)...)
+	}
+
+	sort.SliceStable(paras, func(i, j int) bool {
+		return paras[i].Bounds.Y.Min < paras[j].Bounds.Y.Min
+	})
+	return [][]*Paragraph{paras}
+}
+
+// widestGap finds the widest band along axis that no paragraph crosses and
+// returns its midpoint and width.
+func widestGap(paras []*Paragraph, axis func(gfx.Rect) gfx.Interval) (pos, width float64) {
+	spans := make([]gfx.Interval, len(paras))
+	for i, p := range paras {
+		spans[i] = axis(p.Bounds)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Min < spans[j].Min })
+
+	end := spans[0].Max
+	for _, s := range spans[1:] {
+		if gap := s.Min - end; gap > width {
+			pos, width = end+gap/2, gap
+		}
+		end = math.Max(end, s.Max)
+	}
+	return pos, width
+}
+
+func splitParas(paras []*Paragraph, pos float64, axis func(gfx.Rect) gfx.Interval) (before, after []*Paragraph) {
+	for _, p := range paras {
+		if axis(p.Bounds).Max <= pos {
+			before = append(before, p)
+		} else {
+			after = append(after, p)
+		}
+	}
+	return before, after
+}
+
+func rightToLeft(paras []*Paragraph) bool {
+	n := 0
+	for _, p := range paras {
+		for _, l := range p.Lines {
+			if l.RTL || l.Vertical {
+				n++
+			} else {
+				n--
+			}
+		}
+	}
+	return n > 0
+}
+
+// joinPages joins the text of consecutive pages, skipping empty ones.
+func joinPages(pages []string) string {
+	var builder strings.Builder
+	for _, text := range pages {
+		if text == "" {
+			continue
+		}
+		if builder.Len() > 0 {
+			prev := builder.String()
+			if isHyphenated(prev, text) {
+				trimmed := strings.TrimRightFunc(prev, isHyphen)
+				builder.Reset()
+				builder.WriteString(trimmed)
+			} else {
+				builder.WriteString("\n\n")
+			}
+		}
+		builder.WriteString(text)
+	}
+	return builder.String()
+}
+
+func isHyphenated(prev, next string) bool {
+	if prev == "" || next == "" {
+		return false
+	}
+	last := []rune(prev)
+	if len(last) < 2 || !isHyphen(last[len(last)-1]) || !unicode.IsLetter(last[len(last)-2]) {
+		return false
+	}
+	first := []rune(next)[0]
+	return unicode.IsLower(first)
+}
+
+func isHyphen(r rune) bool {
+	return r == '-' || r == '\u00ad' || r == '\u2010'
+}
+
+func isRTL(r rune) bool {
+	return unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko)
+}
+
+func containsRTL(s string) bool {
+	return strings.IndexFunc(s, isRTL) >= 0
+}
+
+// containsLTR reports whether s holds a letter written left to right.
+func containsLTR(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return unicode.IsLetter(r) && !isRTL(r) }) >= 0
+}
+
+func reverseWords(words []*Word) {
+	for i, j := 0, len(words)-1; i < j; i, j = i+1, j-1 {
+		words[i], words[j] = words[j], words[i]
+	}
+}
+
+func reverseString(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func overlap(a, b gfx.Interval) float64 {
+	return math.Min(a.Max, b.Max) - math.Max(a.Min, b.Min)
+}
+
+func unionRects(a, b gfx.Rect) gfx.Rect {
+	if a == (gfx.Rect{}) {
+		return b
+	}
+	if b == (gfx.Rect{}) {
+		return a
+	}
+	return gfx.Rect{
+		X: gfx.Interval{Min: math.Min(a.X.Min, b.X.Min), Max: math.Max(a.X.Max, b.X.Max)},
+		Y: gfx.Interval{Min: math.Min(a.Y.Min, b.Y.Min), Max: math.Max(a.Y.Max, b.Y.Max)},
+	}
+}
+
+func quadBounds(q gfx.Quad) gfx.Rect {
+	return gfx.Rect{
+		X: gfx.Interval{
+			Min: math.Min(math.Min(q.BottomLeft.X, q.TopLeft.X), math.Min(q.BottomRight.X, q.TopRight.X)),
+			Max: math.Max(math.Max(q.BottomLeft.X, q.TopLeft.X), math.Max(q.BottomRight.X, q.TopRight.X)),
+		},
+		Y: gfx.Interval{
+			Min: math.Min(math.Min(q.BottomLeft.Y, q.TopLeft.Y), math.Min(q.BottomRight.Y, q.TopRight.Y)),
+			Max: math.Max(math.Max(q.BottomLeft.Y, q.TopLeft.Y), math.Max(q.BottomRight.Y, q.TopRight.Y)),
+		},
+	}
+}
+
+func transformPoint(m gfx.Matrix, p gfx.Point) gfx.Point {
+	return gfx.Point{X: p.X*m.A + p.Y*m.C + m.E, Y: p.X*m.B + p.Y*m.D + m.F}
+}
+
+func transformQuad(m gfx.Matrix, q gfx.Quad) gfx.Quad {
+	return gfx.Quad{
+		BottomLeft:  transformPoint(m, q.BottomLeft),
+		TopLeft:     transformPoint(m, q.TopLeft),
+		BottomRight: transformPoint(m, q.BottomRight),
+		TopRight:    transformPoint(m, q.TopRight),
+	}
+}
+
+func distance(a, b gfx.Point) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
@@ -0,0 +1,200 @@
+package fitz_test
+
+import (
+	"image/color"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/bryanmatteson/fitz"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// boxLetter returns an upright letter filling the box from x0, y0 to x1, y1.
+func boxLetter(r rune, x0, y0, x1, y1 float64) fitz.Letter {
+	return fitz.Letter{
+		Rune:   r,
+		Origin: gfx.Point{X: x0, Y: y1},
+		Quad: gfx.Quad{
+			BottomLeft:  gfx.Point{X: x0, Y: y1},
+			TopLeft:     gfx.Point{X: x0, Y: y0},
+			BottomRight: gfx.Point{X: x1, Y: y1},
+			TopRight:    gfx.Point{X: x1, Y: y0},
+		},
+	}
+}
+
+// lineLetters lays out text left to right as 5pt wide letters of size 10,
+// each followed by a gap of tracking points.
+func lineLetters(text string, tracking float64) fitz.Letters {
+	var letters fitz.Letters
+	x := 0.0
+	for _, r := range text {
+		letters = append(letters, boxLetter(r, x, 0, x+5, 10))
+		x += 5 + tracking
+	}
+	return letters
+}
+
+// layoutLines runs the spans through a LayoutDevice and returns the lines
+// of the layout.
+func layoutLines(spans ...*fitz.TextSpan) []*fitz.LayoutLine {
+	dev := fitz.NewLayoutDevice()
+	dev.FillText(&fitz.Text{Spans: spans}, gfx.IdentityMatrix, color.Black)
+
+	var lines []*fitz.LayoutLine
+	for _, col := range dev.Layout(fitz.LayoutOptions{}).Columns {
+		for _, para := range col.Paragraphs {
+			lines = append(lines, para.Lines...)
+		}
+	}
+	return lines
+}
+
+func TestLineWords(t *testing.T) {
+	// gapped is tracked text with wider gaps and no spaces between words
+	var gapped fitz.Letters
+	x := 0.0
+	for _, word := range []string{"Hello", "world", "again"} {
+		for _, l := range lineLetters(word, 0.5) {
+			gapped = append(gapped, boxLetter(l.Rune, l.Quad.TopLeft.X+x, 0, l.Quad.TopRight.X+x, 10))
+		}
+		x += 5.5*5 + 3
+	}
+
+	tests := []struct {
+		name    string
+		letters fitz.Letters
+		words   []string
+		rtl     bool
+	}{
+		{
+			name:    "spaces",
+			letters: lineLetters("Hello  world", 0),
+			words:   []string{"Hello", "world"},
+		},
+		{
+			name:    "gaps",
+			letters: gapped,
+			words:   []string{"Hello", "world", "again"},
+		},
+		{
+			name:    "tracked",
+			letters: lineLetters("Hello", 1.5),
+			words:   []string{"Hello"},
+		},
+		{
+			name:    "punctuation",
+			letters: lineLetters("e.g. (this)", 0),
+			words:   []string{"e.g.", "(this)"},
+		},
+		{
+			// letters come in visual order, words are returned in logical
+			// order
+			name:    "right to left",
+			letters: lineLetters("םלוע םולש", 0),
+			words:   []string{"שלום", "עולם"},
+			rtl:     true,
+		},
+		{
+			// numbers read right to left with the words around them
+			name:    "right to left with numbers",
+			letters: lineLetters("12 34 םולש", 0),
+			words:   []string{"שלום", "34", "12"},
+			rtl:     true,
+		},
+		{
+			name:    "right to left with latin",
+			letters: lineLetters("New York םלוע םולש", 0),
+			words:   []string{"שלום", "עולם", "New", "York"},
+			rtl:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		lines := layoutLines(&fitz.TextSpan{Letters: tt.letters})
+		if len(lines) != 1 {
+			t.Errorf("%s: Layout() = %d lines, want 1", tt.name, len(lines))
+			continue
+		}
+
+		var texts []string
+		for _, w := range lines[0].Words {
+			texts = append(texts, w.Text)
+		}
+		if !reflect.DeepEqual(texts, tt.words) || lines[0].RTL != tt.rtl {
+			t.Errorf("%s: Layout() words = %q, RTL %v, want %q, %v", tt.name, texts, lines[0].RTL, tt.words, tt.rtl)
+		}
+	}
+}
+
+func TestWordQuad(t *testing.T) {
+	quad := func(bl, tl, br, tr gfx.Point) gfx.Quad {
+		return gfx.Quad{BottomLeft: bl, TopLeft: tl, BottomRight: br, TopRight: tr}
+	}
+	pt := func(x, y float64) gfx.Point { return gfx.Point{X: x, Y: y} }
+
+	tests := []struct {
+		name string
+		span *fitz.TextSpan
+		want gfx.Quad
+	}{
+		{
+			name: "left to right",
+			span: &fitz.TextSpan{Letters: fitz.Letters{boxLetter('a', 0, 2, 5, 10), boxLetter('b', 5, 0, 10, 10)}},
+			want: quad(pt(0, 10), pt(0, 0), pt(10, 10), pt(10, 0)),
+		},
+		{
+			// letters of right-to-left words are in visual order
+			name: "right to left",
+			span: &fitz.TextSpan{Letters: lineLetters("םולש", 0)},
+			want: quad(pt(0, 10), pt(0, 0), pt(20, 10), pt(20, 0)),
+		},
+		{
+			name: "vertical",
+			span: &fitz.TextSpan{
+				Letters: fitz.Letters{boxLetter('一', 0, 0, 10, 10), boxLetter('二', 1, 10, 9, 20)},
+				WMode:   fitz.WModeVertical,
+			},
+			want: quad(pt(0, 0), pt(10, 0), pt(0, 20), pt(10, 20)),
+		},
+	}
+
+	for _, tt := range tests {
+		lines := layoutLines(tt.span)
+		if len(lines) != 1 || len(lines[0].Words) != 1 {
+			t.Errorf("%s: Layout() = %d lines, want one line of one word", tt.name, len(lines))
+			continue
+		}
+		if got := lines[0].Words[0].Quad; !quadsEqual(got, tt.want) {
+			t.Errorf("%s: Quad = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func quadsEqual(a, b gfx.Quad) bool {
+	near := func(p, q gfx.Point) bool { return math.Abs(p.X-q.X) < 1e-9 && math.Abs(p.Y-q.Y) < 1e-9 }
+	return near(a.BottomLeft, b.BottomLeft) && near(a.TopLeft, b.TopLeft) &&
+		near(a.BottomRight, b.BottomRight) && near(a.TopRight, b.TopRight)
+}
+
+func TestPageWords(t *testing.T) {
+	words, err := loadPage(t, openSample(t), 0).Words(fitz.LayoutOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) < 2 || words[0].Text != "Hello" || words[1].Text != "World" {
+		t.Fatalf("Words() = %v, want Hello World first", words)
+	}
+
+	// Hello is 27.336pt wide in 12pt Helvetica, on a baseline 72pt from the
+	// top of the page
+	q := words[0].Quad
+	if math.Abs(q.BottomLeft.X-72) > 1 || math.Abs(q.BottomRight.X-99.336) > 1 {
+		t.Errorf("Words() quad of Hello = %v, want it from x 72 to 99.3", q)
+	}
+	if q.TopLeft.Y >= 72 || q.BottomLeft.Y < 72 || words[0].Line != 0 || words[1].Line != 0 {
+		t.Errorf("Words() Hello = %+v, want it around the baseline at y 72 on line 0", words[0])
+	}
+}
@@ -0,0 +1,160 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unsafe"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// LinkKind identifies the type of destination a link points to.
+type LinkKind int
+
+const (
+	// LinkInternal points at a page and location in this document.
+	LinkInternal LinkKind = iota
+	// LinkNamed points at a named destination in this document.
+	LinkNamed
+	// LinkExternal points at a URI outside of the document.
+	LinkExternal
+)
+
+// Link is a hyperlink on a page.
+type Link struct {
+	// Rect is the clickable area in page space.
+	Rect gfx.Rect
+	Kind LinkKind
+	// URI is the raw link target as reported by mupdf.
+	URI string
+	// Name is the named destination for LinkNamed links.
+	Name string
+	// Page is the zero-based target page, or -1 if the link does not
+	// resolve to a page in this document.
+	Page int
+	// Point is the target location on Page.
+	Point gfx.Point
+}
+
+// Links returns the links on the page with their resolved destinations.
+func (p *Page) Links() (links []Link, err error) {
+	p.lockDocument()
+	defer p.unlockDocument()
+	defer catch(&err)
+
+	pg := C.fz_load_page(p.ctx, p.doc, C.int(p.number))
+	defer C.fz_drop_page(p.ctx, pg)
+
+	head := C.fz_load_links(p.ctx, pg)
+	defer C.fz_drop_link(p.ctx, head)
+
+	for link := head; link != nil; link = link.next {
+		links = append(links, p.resolveLink(rectFromFitz(link.rect), C.GoString(link.uri)))
+	}
+
+	return links, nil
+}
+
+func (p *Page) resolveLink(rect gfx.Rect, uri string) Link {
+	link := Link{Rect: rect, URI: uri, Page: -1}
+	if isExternalLink(uri) {
+		link.Kind = LinkExternal
+		return link
+	}
+
+	if name := strings.TrimPrefix(uri, "#"); name != "" && (name[0] < '0' || name[0] > '9') {
+		link.Kind = LinkNamed
+		link.Name = name
+	}
+
+	curi := C.CString(uri)
+	defer C.free(unsafe.Pointer(curi))
+
+	var x, y C.float
+	loc := C.fz_resolve_link(p.ctx, p.doc, curi, &x, &y)
+	if loc.page >= 0 {
+		link.Page = int(C.fz_page_number_from_location(p.ctx, p.doc, loc))
+		link.Point = gfx.MakePoint(float64(x), float64(y))
+	}
+
+	return link
+}
+
+// AddLink creates a link annotation on the page covering link.Rect. The
+// destination is taken from link.URI for LinkExternal links, link.Name for
+// LinkNamed links and link.Page and link.Point otherwise. Named destinations
+// are resolved to their page when the link is created.
+func (p *Page) AddLink(link Link) (err error) {
+	if C.pdf_specifics(p.ctx, p.doc) == nil {
+		return ErrNotPDF
+	}
+
+	p.lockDocument()
+	defer p.unlockDocument()
+	defer catch(&err)
+
+	var uri string
+	switch link.Kind {
+	case LinkExternal:
+		uri = link.URI
+	case LinkNamed:
+		uri = "#" + link.Name
+	default:
+		uri = fmt.Sprintf("#%d,%g,%g", link.Page+1, link.Point.X, link.Point.Y)
+	}
+
+	curi := C.CString(uri)
+	defer C.free(unsafe.Pointer(curi))
+
+	pg := C.fz_load_page(p.ctx, p.doc, C.int(p.number))
+	defer C.fz_drop_page(p.ctx, pg)
+
+	created := C.fz_create_link(p.ctx, pg, rectToFitz(link.Rect), curi)
+	C.fz_drop_link(p.ctx, created)
+	return nil
+}
+
+// DeleteLink removes the link annotation whose area matches link.Rect.
+// ErrLinkMissing is returned if no such link exists.
+func (p *Page) DeleteLink(link Link) (err error) {
+	doc := C.pdf_specifics(p.ctx, p.doc)
+	if doc == nil {
+		return ErrNotPDF
+	}
+
+	p.lockDocument()
+	defer p.unlockDocument()
+	defer catch(&err)
+
+	pageObj := C.pdf_lookup_page_obj(p.ctx, doc, C.int(p.number))
+	annots := C.pdf_dict_get(p.ctx, pageObj, pdfName(C.PDF_ENUM_NAME_Annots))
+
+	var mediabox C.fz_rect
+	var ctm C.fz_matrix
+	C.pdf_page_obj_transform(p.ctx, pageObj, &mediabox, &ctm)
+
+	for i := int(C.pdf_array_len(p.ctx, annots)) - 1; i >= 0; i-- {
+		annot := C.pdf_array_get(p.ctx, annots, C.int(i))
+		subtype := C.pdf_dict_get(p.ctx, annot, pdfName(C.PDF_ENUM_NAME_Subtype))
+		if C.pdf_name_eq(p.ctx, subtype, pdfName(C.PDF_ENUM_NAME_Link)) == 0 {
+			continue
+		}
+
+		rect := C.fz_transform_rect(C.pdf_dict_get_rect(p.ctx, annot, pdfName(C.PDF_ENUM_NAME_Rect)), ctm)
+		if rectsMatch(rectFromFitz(rect), link.Rect) {
+			C.pdf_array_delete(p.ctx, annots, C.int(i))
+			return nil
+		}
+	}
+
+	return ErrLinkMissing
+}
+
+func rectsMatch(a, b gfx.Rect) bool {
+	const eps = 0.5
+	return math.Abs(a.X.Min-b.X.Min) < eps && math.Abs(a.Y.Min-b.Y.Min) < eps &&
+		math.Abs(a.X.Max-b.X.Max) < eps && math.Abs(a.Y.Max-b.Y.Max) < eps
+}
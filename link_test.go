@@ -0,0 +1,104 @@
+package fitz_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/fitz"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// rectNear reports whether a and b are the same rectangle to within half a
+// point.
+func rectNear(a, b gfx.Rect) bool {
+	const eps = 0.5
+	return math.Abs(a.X.Min-b.X.Min) < eps && math.Abs(a.X.Max-b.X.Max) < eps &&
+		math.Abs(a.Y.Min-b.Y.Min) < eps && math.Abs(a.Y.Max-b.Y.Max) < eps
+}
+
+func rectWH(x, y, w, h float64) gfx.Rect {
+	return gfx.Rect{X: gfx.Interval{Min: x, Max: x + w}, Y: gfx.Interval{Min: y, Max: y + h}}
+}
+
+func TestLinks(t *testing.T) {
+	links, err := loadPage(t, openSample(t), 0).Links()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 {
+		t.Fatalf("Links() = %d links, want 1", len(links))
+	}
+
+	link := links[0]
+	if link.Kind != fitz.LinkExternal || link.URI != "https://example.com/" || link.Page != -1 {
+		t.Errorf("Links() = %+v, want an external link to https://example.com/", link)
+	}
+	// the link covers 72 715 150 735 in PDF space, whose origin is at the
+	// bottom of the 792pt high page
+	if want := rectWH(72, 57, 78, 20); !rectNear(link.Rect, want) {
+		t.Errorf("Links() rect = %v, want %v", link.Rect, want)
+	}
+}
+
+func TestAddLink(t *testing.T) {
+	doc := openSample(t)
+	pg := loadPage(t, doc, 0)
+
+	added := []fitz.Link{
+		{Rect: rectWH(72, 100, 100, 20), Kind: fitz.LinkInternal, Page: 1},
+		{Rect: rectWH(72, 130, 100, 20), Kind: fitz.LinkExternal, URI: "mailto:someone@example.com"},
+		{Rect: rectWH(72, 160, 100, 20), Kind: fitz.LinkExternal, URI: "svn+ssh://host/repo"},
+	}
+	for _, link := range added {
+		if err := pg.AddLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links, err := loadPage(t, reopen(t, doc), 0).Links()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range added {
+		found := false
+		for _, link := range links {
+			if !rectNear(link.Rect, want.Rect) {
+				continue
+			}
+			found = true
+			if link.Kind != want.Kind || link.Page != want.Page && want.Kind == fitz.LinkInternal ||
+				link.URI != want.URI && want.Kind == fitz.LinkExternal {
+				t.Errorf("Links() after AddLink = %+v, want %+v", link, want)
+			}
+		}
+		if !found {
+			t.Errorf("Links() after AddLink has no link at %v", want.Rect)
+		}
+	}
+}
+
+func TestDeleteLink(t *testing.T) {
+	doc := openSample(t)
+	pg := loadPage(t, doc, 0)
+
+	links, err := pg.Links()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) == 0 {
+		t.Fatal("Links() = no links, want 1")
+	}
+
+	if err := pg.DeleteLink(links[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := pg.DeleteLink(links[0]); err != fitz.ErrLinkMissing {
+		t.Errorf("DeleteLink() of a deleted link = %v, want %v", err, fitz.ErrLinkMissing)
+	}
+
+	if links, err := loadPage(t, reopen(t, doc), 0).Links(); err != nil || len(links) != 0 {
+		t.Errorf("Links() after DeleteLink = %d links, %v, want none", len(links), err)
+	}
+}
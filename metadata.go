@@ -0,0 +1,251 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// Metadata is the document information of a document. Format, Encryption
+// and Version are read-only.
+type Metadata struct {
+	// Format describes the document format, e.g. "PDF 1.7" or "EPUB".
+	Format string
+	// Encryption describes the encryption method, or "None".
+	Encryption string
+	// Version is the PDF version, e.g. "1.7". It is empty for other
+	// formats.
+	Version string
+
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	Creator  string
+	Producer string
+	Created  time.Time
+	Modified time.Time
+}
+
+// Metadata returns the document information. Fields the document does not
+// provide are left empty.
+func (d *Document) Metadata() (meta Metadata, err error) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	defer catch(&err)
+
+	meta = Metadata{
+		Format:     lookupMetadata(d.ctx, d.native, C.FZ_META_FORMAT),
+		Encryption: lookupMetadata(d.ctx, d.native, C.FZ_META_ENCRYPTION),
+		Title:      lookupMetadata(d.ctx, d.native, "info:Title"),
+		Author:     lookupMetadata(d.ctx, d.native, "info:Author"),
+		Subject:    lookupMetadata(d.ctx, d.native, "info:Subject"),
+		Keywords:   lookupMetadata(d.ctx, d.native, "info:Keywords"),
+		Creator:    lookupMetadata(d.ctx, d.native, "info:Creator"),
+		Producer:   lookupMetadata(d.ctx, d.native, "info:Producer"),
+		Created:    parsePDFDate(lookupMetadata(d.ctx, d.native, "info:CreationDate")),
+		Modified:   parsePDFDate(lookupMetadata(d.ctx, d.native, "info:ModDate")),
+	}
+
+	if d.pdf != nil {
+		v := int(C.pdf_version(d.ctx, d.pdf))
+		meta.Version = fmt.Sprintf("%d.%d", v/10, v%10)
+	}
+
+	return meta, nil
+}
+
+// SetMetadata writes the document information dictionary. Empty strings and
+// zero times remove the corresponding entry.
+func (d *Document) SetMetadata(meta Metadata) (err error) {
+	if d.pdf == nil {
+		return ErrNotPDF
+	}
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	defer catch(&err)
+
+	trailer := C.pdf_trailer(d.ctx, d.pdf)
+	info := C.pdf_dict_get(d.ctx, trailer, pdfName(C.PDF_ENUM_NAME_Info))
+	if info == nil {
+		info = C.pdf_add_new_dict(d.ctx, d.pdf, 8)
+		C.pdf_dict_put_drop(d.ctx, trailer, pdfName(C.PDF_ENUM_NAME_Info), info)
+	}
+
+	setInfoText(d.ctx, info, "Title", meta.Title)
+	setInfoText(d.ctx, info, "Author", meta.Author)
+	setInfoText(d.ctx, info, "Subject", meta.Subject)
+	setInfoText(d.ctx, info, "Keywords", meta.Keywords)
+	setInfoText(d.ctx, info, "Creator", meta.Creator)
+	setInfoText(d.ctx, info, "Producer", meta.Producer)
+	setInfoDate(d.ctx, info, "CreationDate", meta.Created)
+	setInfoDate(d.ctx, info, "ModDate", meta.Modified)
+
+	return nil
+}
+
+// XMP returns the raw XMP metadata packet of the document catalog, or nil if
+// there is none.
+func (d *Document) XMP() (data []byte, err error) {
+	if d.pdf == nil {
+		return nil, ErrNotPDF
+	}
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	defer catch(&err)
+
+	obj := C.pdf_dict_get(d.ctx, pdfCatalog(d.ctx, d.pdf), pdfName(C.PDF_ENUM_NAME_Metadata))
+	if obj == nil {
+		return nil, nil
+	}
+
+	buf := C.pdf_load_stream(d.ctx, obj)
+	defer C.fz_drop_buffer(d.ctx, buf)
+
+	var ptr *C.uchar
+	n := C.fz_buffer_storage(d.ctx, buf, &ptr)
+	return C.GoBytes(unsafe.Pointer(ptr), C.int(n)), nil
+}
+
+// SetXMP replaces the XMP metadata packet of the document catalog. An empty
+// packet removes it.
+func (d *Document) SetXMP(data []byte) (err error) {
+	if d.pdf == nil {
+		return ErrNotPDF
+	}
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	defer catch(&err)
+
+	root := pdfCatalog(d.ctx, d.pdf)
+	if len(data) == 0 {
+		C.pdf_dict_del(d.ctx, root, pdfName(C.PDF_ENUM_NAME_Metadata))
+		return nil
+	}
+
+	buf := C.fz_new_buffer_from_copied_data(d.ctx, (*C.uchar)(unsafe.Pointer(&data[0])), C.size_t(len(data)))
+	defer C.fz_drop_buffer(d.ctx, buf)
+
+	if obj := C.pdf_dict_get(d.ctx, root, pdfName(C.PDF_ENUM_NAME_Metadata)); obj != nil {
+		C.pdf_update_stream(d.ctx, d.pdf, obj, buf, 0)
+		return nil
+	}
+
+	dict := C.pdf_new_dict(d.ctx, d.pdf, 2)
+	defer C.pdf_drop_obj(d.ctx, dict)
+	C.pdf_dict_put(d.ctx, dict, pdfName(C.PDF_ENUM_NAME_Type), pdfName(C.PDF_ENUM_NAME_Metadata))
+	C.pdf_dict_put(d.ctx, dict, pdfName(C.PDF_ENUM_NAME_Subtype), pdfName(C.PDF_ENUM_NAME_XML))
+
+	C.pdf_dict_put_drop(d.ctx, root, pdfName(C.PDF_ENUM_NAME_Metadata), C.pdf_add_stream(d.ctx, d.pdf, buf, dict, 0))
+	return nil
+}
+
+func lookupMetadata(ctx *C.fz_context, doc *C.fz_document, key string) string {
+	ckey := C.CString(key)
+	defer C.free(unsafe.Pointer(ckey))
+
+	n := C.fz_lookup_metadata(ctx, doc, ckey, nil, 0)
+	if n <= 1 {
+		return ""
+	}
+
+	buf := (*C.char)(C.malloc(C.size_t(n)))
+	defer C.free(unsafe.Pointer(buf))
+
+	C.fz_lookup_metadata(ctx, doc, ckey, buf, n)
+	return C.GoString(buf)
+}
+
+func setInfoText(ctx *C.fz_context, info *C.pdf_obj, key, value string) {
+	ckey := C.CString(key)
+	defer C.free(unsafe.Pointer(ckey))
+
+	if value == "" {
+		C.pdf_dict_dels(ctx, info, ckey)
+		return
+	}
+
+	cvalue := C.CString(value)
+	defer C.free(unsafe.Pointer(cvalue))
+	C.pdf_dict_puts_drop(ctx, info, ckey, C.pdf_new_text_string(ctx, cvalue))
+}
+
+func setInfoDate(ctx *C.fz_context, info *C.pdf_obj, key string, t time.Time) {
+	ckey := C.CString(key)
+	defer C.free(unsafe.Pointer(ckey))
+
+	if t.IsZero() {
+		C.pdf_dict_dels(ctx, info, ckey)
+		return
+	}
+
+	date := formatPDFDate(t)
+	cdate := C.CString(date)
+	defer C.free(unsafe.Pointer(cdate))
+	C.pdf_dict_puts_drop(ctx, info, ckey, C.pdf_new_string(ctx, cdate, C.size_t(len(date))))
+}
+
+// formatPDFDate formats t as a PDF date string, D:YYYYMMDDHHmmSSOHH'mm'.
+func formatPDFDate(t time.Time) string {
+	_, offset := t.Zone()
+	if offset == 0 {
+		return t.Format("D:20060102150405Z")
+	}
+
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("%s%c%02d'%02d'", t.Format("D:20060102150405"), sign, offset/3600, offset/60%60)
+}
+
+// parsePDFDate parses a PDF date string. Missing trailing fields take their
+// default values and a missing time zone is treated as UTC. It returns the
+// zero time if s is not a date.
+func parsePDFDate(s string) time.Time {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "D:")
+
+	// year, month, day, hour, minute, second
+	fields := [6]int{0, 1, 1, 0, 0, 0}
+	widths := [6]int{4, 2, 2, 2, 2, 2}
+	for i, w := range widths {
+		if len(s) < w {
+			if i == 0 {
+				return time.Time{}
+			}
+			break
+		}
+		v, err := strconv.Atoi(s[:w])
+		if err != nil {
+			if i == 0 {
+				return time.Time{}
+			}
+			break
+		}
+		fields[i], s = v, s[w:]
+	}
+
+	loc := time.UTC
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		sign := 1
+		if s[0] == '-' {
+			sign = -1
+		}
+		tz := strings.Split(strings.TrimSuffix(s[1:], "'"), "'")
+		hh, _ := strconv.Atoi(tz[0])
+		mm := 0
+		if len(tz) > 1 {
+			mm, _ = strconv.Atoi(tz[1])
+		}
+		loc = time.FixedZone("", sign*(hh*3600+mm*60))
+	}
+
+	return time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, loc)
+}
@@ -0,0 +1,87 @@
+package fitz_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bryanmatteson/fitz"
+)
+
+func TestMetadata(t *testing.T) {
+	meta, err := openSample(t).Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if meta.Title != "Sample" || meta.Author != "fitz" || meta.Subject != "" {
+		t.Errorf("Metadata() = %+v, want title Sample by fitz and no subject", meta)
+	}
+	if meta.Format != "PDF 1.7" || meta.Version != "1.7" {
+		t.Errorf("Metadata() format = %q, version %q, want PDF 1.7", meta.Format, meta.Version)
+	}
+	if want := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC); !meta.Created.Equal(want) || !meta.Modified.IsZero() {
+		t.Errorf("Metadata() dates = %v, %v, want %v and none", meta.Created, meta.Modified, want)
+	}
+}
+
+func TestSetMetadata(t *testing.T) {
+	doc := openSample(t)
+
+	want := fitz.Metadata{
+		Title:    "Résumé",
+		Subject:  "Testing",
+		Keywords: "pdf, metadata",
+		Created:  time.Date(2021, 6, 15, 12, 30, 0, 0, time.FixedZone("", 5*3600+30*60)),
+		Modified: time.Date(2021, 6, 16, 8, 0, 0, 0, time.FixedZone("", -8*3600)),
+	}
+	if err := doc.SetMetadata(want); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := reopen(t, doc).Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the empty author removes the one of the sample
+	if meta.Title != want.Title || meta.Author != "" || meta.Subject != want.Subject || meta.Keywords != want.Keywords {
+		t.Errorf("Metadata() after SetMetadata = %+v, want %+v", meta, want)
+	}
+	for _, d := range []struct {
+		name      string
+		got, want time.Time
+	}{
+		{"Created", meta.Created, want.Created},
+		{"Modified", meta.Modified, want.Modified},
+	} {
+		_, gotOffset := d.got.Zone()
+		_, wantOffset := d.want.Zone()
+		if !d.got.Equal(d.want) || gotOffset != wantOffset {
+			t.Errorf("Metadata() after SetMetadata %s = %v, want %v", d.name, d.got, d.want)
+		}
+	}
+}
+
+func TestSetXMP(t *testing.T) {
+	doc := openSample(t)
+
+	if data, err := doc.XMP(); err != nil || data != nil {
+		t.Fatalf("XMP() = %q, %v, want none", data, err)
+	}
+
+	packet := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"></x:xmpmeta>`)
+	if err := doc.SetXMP(packet); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := reopen(t, doc).XMP(); err != nil || !bytes.Equal(data, packet) {
+		t.Errorf("XMP() after SetXMP = %q, %v, want %q", data, err, packet)
+	}
+
+	if err := doc.SetXMP(nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := reopen(t, doc).XMP(); err != nil || data != nil {
+		t.Errorf("XMP() after removing it = %q, %v, want none", data, err)
+	}
+}
@@ -0,0 +1,238 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+	"unsafe"
+)
+
+// OCROptions controls optical character recognition. OCR needs mupdf built
+// with Tesseract support; otherwise it fails with an error.
+type OCROptions struct {
+	// Language is the Tesseract language to recognise. Defaults to "eng".
+	Language string
+	// DPI is the resolution pages are rendered at for recognition.
+	// Defaults to 300.
+	DPI float64
+	// Force makes AddOCRLayer recognise pages that already have text.
+	Force bool
+}
+
+func (o OCROptions) withDefaults() OCROptions {
+	if o.Language == "" {
+		o.Language = "eng"
+	}
+	if o.DPI <= 0 {
+		o.DPI = 300
+	}
+	return o
+}
+
+// SetOCRDataPath sets the directory Tesseract loads its .traineddata files
+// from. Tesseract only reads it from the TESSDATA_PREFIX environment
+// variable, so this is a process-wide setting: call it once at start-up,
+// before any OCR runs and before other goroutines may read the environment.
+func SetOCRDataPath(dir string) error {
+	return os.Setenv("TESSDATA_PREFIX", dir)
+}
+
+// ocrFontName is the resource name of the font used for OCR text layers.
+const ocrFontName = "OCRText"
+
+// OCR recognises the text in the rendered image of the page. The result is
+// in page coordinates, like StructuredText.
+func (p *Page) OCR(opts OCROptions) (st *StructuredText, err error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	defer catch(&err)
+
+	text := newOCRStextPage(p.ctx, p.list, p.bounds, opts.withDefaults())
+	defer C.fz_drop_stext_page(p.ctx, text)
+
+	return structuredTextFromFitz(p.ctx, text), nil
+}
+
+// AddOCRLayer recognises the text of every page that has none and writes it
+// into the page as invisible text, so that GetText, Search and selection
+// work on scanned documents.
+func (d *Document) AddOCRLayer(opts OCROptions) (err error) {
+	if d.pdf == nil {
+		return ErrNotPDF
+	}
+
+	opts = opts.withDefaults()
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	defer catch(&err)
+
+	name := C.CString("Helvetica")
+	font := C.fz_new_base14_font(d.ctx, name)
+	C.free(unsafe.Pointer(name))
+	defer C.fz_drop_font(d.ctx, font)
+
+	var fontObj *C.pdf_obj
+	defer func() {
+		if fontObj != nil {
+			C.pdf_drop_obj(d.ctx, fontObj)
+		}
+	}()
+
+	numPages := int(C.pdf_count_pages(d.ctx, d.pdf))
+	for i := 0; i < numPages; i++ {
+		pg := C.pdf_load_page(d.ctx, d.pdf, C.int(i))
+		changed := ocrPage(d.ctx, d.pdf, pg, font, &fontObj, opts)
+
+		if page, ok := d.pages[i]; ok && changed {
+			page.lockedReload(&pg.super)
+		}
+		C.fz_drop_page(d.ctx, &pg.super)
+	}
+
+	return nil
+}
+
+// ocrPage adds an invisible text layer to a page. The font is added to the
+// document the first time it is needed.
+func ocrPage(ctx *C.fz_context, doc *C.pdf_document, pg *C.pdf_page, font *C.fz_font, fontObj **C.pdf_obj, opts OCROptions) bool {
+	list := C.fz_new_display_list_from_page(ctx, &pg.super)
+	defer C.fz_drop_display_list(ctx, list)
+	bounds := C.fz_bound_page(ctx, &pg.super)
+
+	if !opts.Force && hasText(ctx, list, bounds) {
+		return false
+	}
+
+	text := newOCRStextPage(ctx, list, bounds, opts)
+	defer C.fz_drop_stext_page(ctx, text)
+
+	var mediabox C.fz_rect
+	var pageCTM C.fz_matrix
+	C.pdf_page_transform(ctx, pg, &mediabox, &pageCTM)
+	toPDF := C.fz_invert_matrix(pageCTM)
+
+	var content strings.Builder
+	for block := text.first_block; block != nil; block = block.next {
+		for line := C.fzgo_stext_block_first_line(block); line != nil; line = line.next {
+			dx, dy := float64(line.dir.x), float64(line.dir.y)
+
+			for ch := line.first_char; ch != nil; ch = ch.next {
+				if unicode.IsSpace(rune(ch.c)) {
+					continue
+				}
+
+				gid := C.fz_encode_character(ctx, font, ch.c)
+				size := float64(ch.size)
+
+				// stretch the glyph to the width of the recognised character
+				// so that selections line up with the image
+				stretch := 1.0
+				width := math.Hypot(float64(ch.quad.lr.x-ch.quad.ll.x), float64(ch.quad.lr.y-ch.quad.ll.y))
+				if adv := float64(C.fz_advance_glyph(ctx, font, gid, 0)); adv > 0 && size > 0 {
+					stretch = width / (adv * size)
+				}
+
+				trm := C.fz_make_matrix(
+					C.float(dx*stretch*size), C.float(dy*stretch*size),
+					C.float(dy*size), C.float(-dx*size),
+					ch.origin.x, ch.origin.y,
+				)
+				m := C.fz_concat(trm, toPDF)
+				fmt.Fprintf(&content, "%g %g %g %g %g %g Tm <%04x> Tj\n", m.a, m.b, m.c, m.d, m.e, m.f, int(gid))
+			}
+		}
+	}
+
+	if content.Len() == 0 {
+		return false
+	}
+
+	if *fontObj == nil {
+		*fontObj = C.pdf_add_cid_font(ctx, doc, font)
+	}
+
+	resources := pageResources(ctx, doc, pg)
+	fonts := C.pdf_dict_get(ctx, resources, pdfName(C.PDF_ENUM_NAME_Font))
+	if fonts == nil {
+		fonts = C.pdf_dict_put_dict(ctx, resources, pdfName(C.PDF_ENUM_NAME_Font), 2)
+	}
+	key := C.CString(ocrFontName)
+	C.pdf_dict_puts(ctx, fonts, key, *fontObj)
+	C.free(unsafe.Pointer(key))
+
+	appendPageContent(ctx, doc, pg, fmt.Sprintf("BT\n3 Tr\n/%s 1 Tf\n%sET\n", ocrFontName, content.String()))
+	return true
+}
+
+// hasText reports whether the display list draws any visible characters.
+func hasText(ctx *C.fz_context, list *C.fz_display_list, bounds C.fz_rect) bool {
+	text := newStextPage(ctx, list, bounds, 0, nil)
+	defer C.fz_drop_stext_page(ctx, text)
+
+	for block := text.first_block; block != nil; block = block.next {
+		for line := C.fzgo_stext_block_first_line(block); line != nil; line = line.next {
+			for ch := line.first_char; ch != nil; ch = ch.next {
+				if !unicode.IsSpace(rune(ch.c)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// newOCRStextPage renders the display list at the requested resolution, runs
+// it through Tesseract and returns the recognised text in page coordinates.
+// The caller must drop the result.
+func newOCRStextPage(ctx *C.fz_context, list *C.fz_display_list, bounds C.fz_rect, opts OCROptions) *C.fz_stext_page {
+	scale := C.float(opts.DPI / 72)
+	ctm := C.fz_scale(scale, scale)
+	area := C.fz_transform_rect(bounds, ctm)
+
+	text := C.fz_new_stext_page(ctx, area)
+
+	var stextOpts C.fz_stext_options
+	target := C.fz_new_stext_device(ctx, text, &stextOpts)
+	defer C.fz_drop_device(ctx, target)
+
+	language := C.CString(opts.Language)
+	defer C.free(unsafe.Pointer(language))
+
+	device := C.fz_new_ocr_device(ctx, target, ctm, bounds, 0, language, nil, nil)
+	C.fz_enable_device_hints(ctx, device, C.FZ_NO_CACHE)
+	defer C.fz_drop_device(ctx, device)
+
+	var cookie C.fz_cookie
+	C.fz_run_display_list(ctx, list, device, ctm, area, &cookie)
+	C.fz_close_device(ctx, device)
+	C.fz_close_device(ctx, target)
+
+	// the recognised text comes back in pixels
+	transformStextPage(text, C.fz_scale(1/scale, 1/scale))
+	return text
+}
+
+func transformStextPage(text *C.fz_stext_page, m C.fz_matrix) {
+	expansion := C.fz_matrix_expansion(m)
+
+	text.mediabox = C.fz_transform_rect(text.mediabox, m)
+	for block := text.first_block; block != nil; block = block.next {
+		block.bbox = C.fz_transform_rect(block.bbox, m)
+		if block._type == C.FZ_STEXT_BLOCK_IMAGE {
+			C.fzgo_stext_block_set_transform(block, C.fz_concat(C.fzgo_stext_block_transform(block), m))
+		}
+		for line := C.fzgo_stext_block_first_line(block); line != nil; line = line.next {
+			line.bbox = C.fz_transform_rect(line.bbox, m)
+			for ch := line.first_char; ch != nil; ch = ch.next {
+				ch.origin = C.fz_transform_point(ch.origin, m)
+				ch.quad = C.fz_transform_quad(ch.quad, m)
+				ch.size *= expansion
+			}
+		}
+	}
+}
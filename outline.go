@@ -0,0 +1,176 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"unsafe"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// Outline is an entry in the document outline (bookmarks).
+type Outline struct {
+	Title string
+	// URI is the destination of the entry. Internal destinations start
+	// with '#'; anything containing a scheme is an external link.
+	URI string
+	// Page is the zero-based target page, or -1 for external links and
+	// entries without a destination.
+	Page int
+	// Point is the target location on Page.
+	Point    gfx.Point
+	Open     bool
+	Children []*Outline
+}
+
+// IsExternal reports whether the entry points outside of the document.
+func (o *Outline) IsExternal() bool { return o.Page < 0 && isExternalLink(o.URI) }
+
+// Outline loads the document outline. A document without an outline
+// returns an empty slice.
+func (d *Document) Outline() (outline []*Outline, err error) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	defer catchAs(&err, ErrLoadOutline)
+
+	root := C.fz_load_outline(d.ctx, d.native)
+	if root == nil {
+		return nil, nil
+	}
+	defer C.fz_drop_outline(d.ctx, root)
+
+	return outlineFromFitz(root), nil
+}
+
+func outlineFromFitz(node *C.fz_outline) []*Outline {
+	var items []*Outline
+	for ; node != nil; node = node.next {
+		items = append(items, &Outline{
+			Title:    C.GoString(node.title),
+			URI:      C.GoString(node.uri),
+			Page:     int(node.page),
+			Point:    gfx.MakePoint(float64(node.x), float64(node.y)),
+			Open:     node.is_open != 0,
+			Children: outlineFromFitz(node.down),
+		})
+	}
+	return items
+}
+
+// SetOutline replaces the document outline. Entries are edited as plain Go
+// values: load the tree with Outline, then add, reorder, rename or remove
+// entries and pass the result back. Passing an empty outline removes it.
+// The change is persisted by Save and Write.
+func (d *Document) SetOutline(outline []*Outline) (err error) {
+	if d.pdf == nil {
+		return ErrNotPDF
+	}
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	defer catch(&err)
+
+	d.setOutline(outline)
+	return nil
+}
+
+func (d *Document) setOutline(outline []*Outline) {
+	root := pdfCatalog(d.ctx, d.pdf)
+	if len(outline) == 0 {
+		C.pdf_dict_del(d.ctx, root, pdfName(C.PDF_ENUM_NAME_Outlines))
+		return
+	}
+
+	outlines := C.pdf_add_object_drop(d.ctx, d.pdf, C.pdf_new_dict(d.ctx, d.pdf, 4))
+	C.pdf_dict_put(d.ctx, outlines, pdfName(C.PDF_ENUM_NAME_Type), pdfName(C.PDF_ENUM_NAME_Outlines))
+
+	count := d.writeOutlineItems(outlines, outline)
+	C.pdf_dict_put_int(d.ctx, outlines, pdfName(C.PDF_ENUM_NAME_Count), C.int64_t(count))
+	C.pdf_dict_put_drop(d.ctx, root, pdfName(C.PDF_ENUM_NAME_Outlines), outlines)
+}
+
+// writeOutlineItems writes items as the children of parent and returns the
+// number of entries visible when parent is open.
+func (d *Document) writeOutlineItems(parent *C.pdf_obj, items []*Outline) int {
+	numPages := int(C.pdf_count_pages(d.ctx, d.pdf))
+	visible := 0
+
+	var prev *C.pdf_obj
+	for _, item := range items {
+		obj := C.pdf_add_object_drop(d.ctx, d.pdf, C.pdf_new_dict(d.ctx, d.pdf, 8))
+
+		title := C.CString(item.Title)
+		C.pdf_dict_put_text_string(d.ctx, obj, pdfName(C.PDF_ENUM_NAME_Title), title)
+		C.free(unsafe.Pointer(title))
+
+		C.pdf_dict_put(d.ctx, obj, pdfName(C.PDF_ENUM_NAME_Parent), parent)
+
+		if item.Page >= 0 && item.Page < numPages {
+			C.pdf_dict_put_drop(d.ctx, obj, pdfName(C.PDF_ENUM_NAME_Dest), pdfLinkDest(d.ctx, d.pdf, item.Page, item.Point))
+		} else if item.URI != "" && isExternalLink(item.URI) {
+			C.pdf_dict_put_drop(d.ctx, obj, pdfName(C.PDF_ENUM_NAME_A), pdfURIAction(d.ctx, d.pdf, item.URI))
+		}
+
+		if prev == nil {
+			C.pdf_dict_put(d.ctx, parent, pdfName(C.PDF_ENUM_NAME_First), obj)
+		} else {
+			C.pdf_dict_put(d.ctx, prev, pdfName(C.PDF_ENUM_NAME_Next), obj)
+			C.pdf_dict_put(d.ctx, obj, pdfName(C.PDF_ENUM_NAME_Prev), prev)
+			C.pdf_drop_obj(d.ctx, prev)
+		}
+
+		visible++
+		if len(item.Children) > 0 {
+			n := d.writeOutlineItems(obj, item.Children)
+			if item.Open {
+				visible += n
+			} else {
+				n = -n
+			}
+			C.pdf_dict_put_int(d.ctx, obj, pdfName(C.PDF_ENUM_NAME_Count), C.int64_t(n))
+		}
+
+		prev = obj
+	}
+
+	if prev != nil {
+		C.pdf_dict_put(d.ctx, parent, pdfName(C.PDF_ENUM_NAME_Last), prev)
+		C.pdf_drop_obj(d.ctx, prev)
+	}
+
+	return visible
+}
+
+// remapOutline returns a copy of outline whose internal targets are
+// renumbered through pages, which maps source page numbers to destination
+// page numbers. Entries whose target page was not kept are dropped and their
+// surviving children are promoted in their place.
+func remapOutline(outline []*Outline, pages map[int]int) []*Outline {
+	var items []*Outline
+	for _, item := range outline {
+		children := remapOutline(item.Children, pages)
+
+		var keep bool
+		page := item.Page
+		if page >= 0 {
+			page, keep = pages[item.Page]
+		} else {
+			keep = item.URI != "" && isExternalLink(item.URI)
+		}
+
+		if !keep {
+			items = append(items, children...)
+			continue
+		}
+
+		items = append(items, &Outline{
+			Title:    item.Title,
+			URI:      item.URI,
+			Page:     page,
+			Point:    item.Point,
+			Open:     item.Open,
+			Children: children,
+		})
+	}
+	return items
+}
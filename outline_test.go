@@ -0,0 +1,95 @@
+package fitz_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bryanmatteson/fitz"
+)
+
+// outlineString lists the titles of the entries with their target pages,
+// or their URI for external links, and their children in brackets.
+func outlineString(items []*fitz.Outline) string {
+	var parts []string
+	for _, item := range items {
+		s := fmt.Sprintf("%s:%d", item.Title, item.Page)
+		if item.IsExternal() {
+			s = fmt.Sprintf("%s:%s", item.Title, item.URI)
+		}
+		if len(item.Children) > 0 {
+			s += outlineString(item.Children)
+		}
+		parts = append(parts, s)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func TestOutline(t *testing.T) {
+	doc := openSample(t)
+
+	outline, err := doc.Outline()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := outlineString(outline), "[Chapter 1:0 Chapter 2:1]"; got != want {
+		t.Errorf("Outline() = %s, want %s", got, want)
+	}
+}
+
+func TestSetOutline(t *testing.T) {
+	doc := openSample(t)
+
+	err := doc.SetOutline([]*fitz.Outline{
+		{Title: "Part", Page: 0, Open: true, Children: []*fitz.Outline{
+			{Title: "Chapter 2", Page: 1},
+		}},
+		{Title: "Web", URI: "https://example.com/", Page: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outline, err := reopen(t, doc).Outline()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := outlineString(outline), "[Part:0[Chapter 2:1] Web:https://example.com/]"; got != want {
+		t.Errorf("Outline() after SetOutline = %s, want %s", got, want)
+	}
+
+	if err := doc.SetOutline(nil); err != nil {
+		t.Fatal(err)
+	}
+	if outline, err := reopen(t, doc).Outline(); err != nil || len(outline) != 0 {
+		t.Errorf("Outline() after removing it = %s, %v, want none", outlineString(outline), err)
+	}
+}
+
+func TestNewDocumentFromPagesOutline(t *testing.T) {
+	doc := openSample(t)
+
+	tests := []struct {
+		pages []int
+		want  string
+	}{
+		{[]int{1}, "[Chapter 2:0]"},
+		{[]int{1, 0}, "[Chapter 1:1 Chapter 2:0]"},
+		{[]int{0, 0}, "[Chapter 1:0]"},
+	}
+
+	for _, tt := range tests {
+		newDoc, err := doc.NewDocumentFromPages(tt.pages...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outline, err := newDoc.Outline()
+		newDoc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := outlineString(outline); got != tt.want {
+			t.Errorf("NewDocumentFromPages(%v) outline = %s, want %s", tt.pages, got, tt.want)
+		}
+	}
+}
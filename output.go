@@ -14,15 +14,30 @@ import (
 
 //export gooutput_writer_write
 func gooutput_writer_write(ctx *C.fz_context, state unsafe.Pointer, data unsafe.Pointer, length C.size_t) {
-	output := pointer.Restore(state).(*outputwriter)
+	output := pointer.Restore(state).(io.Writer)
 	buffer := C.GoBytes(data, C.int(length))
 	output.Write(buffer)
 }
 
+//export gooutput_stream_write
+func gooutput_stream_write(ctx *C.fz_context, state unsafe.Pointer, data unsafe.Pointer, length C.size_t) C.int {
+	output := pointer.Restore(state).(*streamwriter)
+	if output.err != nil {
+		// the failure has been raised already, so what is flushed when the
+		// output is closed or dropped goes nowhere
+		return 0
+	}
+	if _, err := output.Write(C.GoBytes(data, C.int(length))); err != nil {
+		return 1
+	}
+	return 0
+}
+
 //export gooutput_writer_close
 func gooutput_writer_close(ctx *C.fz_context, state unsafe.Pointer) {
-	output := pointer.Restore(state).(*outputwriter)
-	output.Destination.Write(output.Bytes())
+	if output, ok := pointer.Restore(state).(*outputwriter); ok {
+		output.Destination.Write(output.Bytes())
+	}
 }
 
 //export gooutput_writer_tell
@@ -57,6 +72,43 @@ func newOutputForWriter(ctx *C.fz_context, bufferSize int, w io.Writer) *C.fz_ou
 	return C.fzgo_new_output_writer(ctx, C.int(bufferSize), pointer.Save(writer))
 }
 
+// streamwriter passes output straight through to its destination. It is
+// used for formats that are written sequentially and never seek.
+type streamwriter struct {
+	dest io.Writer
+	err  error
+}
+
+func (s *streamwriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	n, err := s.dest.Write(p)
+	s.err = err
+	return n, err
+}
+
+// catch recovers from the exception raised when a write fails and reports
+// the write error in its place. Other panics are passed on to catch. Defer
+// it once the output is created.
+func (s *streamwriter) catch(err *error) {
+	if r := recover(); r != nil {
+		if s.err == nil {
+			panic(r)
+		}
+		*err = s.err
+	}
+}
+
+// newStreamOutputForWriter creates an output that writes to w as data is
+// produced instead of buffering it until close. The first write error
+// aborts the output with an exception and is recorded in the returned
+// streamwriter.
+func newStreamOutputForWriter(ctx *C.fz_context, bufferSize int, w io.Writer) (*C.fz_output, *streamwriter) {
+	writer := &streamwriter{dest: w}
+	return C.fzgo_new_stream_output_writer(ctx, C.int(bufferSize), pointer.Save(writer)), writer
+}
+
 type WriterSeeker struct {
 	buf bytes.Buffer
 	pos int
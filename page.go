@@ -3,9 +3,9 @@ package fitz
 // #include "bridge.h"
 import "C"
 import (
+	"context"
 	"image"
 	"sync"
-	"unsafe"
 
 	"github.com/bryanmatteson/gfx"
 	"github.com/mattn/go-pointer"
@@ -14,24 +14,26 @@ import (
 type Page struct {
 	number int
 	mut    sync.Mutex
+	docMut *sync.Mutex
 	ctx    *C.fz_context
+	doc    *C.fz_document
 	list   *C.fz_display_list
 	bounds C.fz_rect
 }
 
-func newPage(doc *C.pdf_document, docCtx *C.fz_context, number int) *Page {
-	pg := C.pdf_load_page(docCtx, doc, C.int(number))
-	defer C.fz_drop_page(docCtx, &pg.super)
+func newPage(doc *C.fz_document, docCtx *C.fz_context, docMut *sync.Mutex, number int) *Page {
+	pg := C.fz_load_page(docCtx, doc, C.int(number))
+	defer C.fz_drop_page(docCtx, pg)
 
-	list := C.fz_new_display_list_from_page(docCtx, &pg.super)
-	bounds := C.fz_bound_page(docCtx, &pg.super)
+	list := C.fz_new_display_list_from_page(docCtx, pg)
+	bounds := C.fz_bound_page(docCtx, pg)
 
 	// ctx := C.fz_clone_context(docCtx)
 	// userCtx := newusercontext()
 	// userCtx.fontCache.init(ctx, doc, pg)
 	// ctx.user = pointer.Save(userCtx)
 
-	return &Page{ctx: docCtx, number: number, bounds: bounds, list: list}
+	return &Page{ctx: docCtx, doc: doc, docMut: docMut, number: number, bounds: bounds, list: list}
 }
 
 func (p *Page) drop() {
@@ -39,73 +41,98 @@ func (p *Page) drop() {
 	// C.fz_drop_context(p.ctx)
 	p.list = nil
 	p.ctx = nil
+	p.doc = nil
 }
 
-func (p *Page) Number() int      { return p.number }
-func (p *Page) Bounds() gfx.Rect { return rectFromFitz(p.bounds) }
-
-func (p *Page) RenderImage(region gfx.Rect, scale float64) (img *image.RGBA, err error) {
-	p.mut.Lock()
-	defer p.mut.Unlock()
-
-	img = &image.RGBA{}
-	bounds := p.bounds
-	ctm := C.fz_identity
-
-	if scale > 0 {
-		ctm = C.fz_scale(C.float(scale), C.float(scale))
-	}
-
-	if !region.IsEmpty() {
-		region = region.Intersection(p.Bounds())
-		bounds = C.fz_make_rect(C.float(region.X.Min), C.float(region.Y.Min), C.float(region.X.Max), C.float(region.Y.Max))
+// loadPDFPage loads the underlying PDF page. The caller must drop it.
+func (p *Page) loadPDFPage() (*C.pdf_page, error) {
+	doc := C.pdf_specifics(p.ctx, p.doc)
+	if doc == nil {
+		return nil, ErrNotPDF
 	}
+	return C.pdf_load_page(p.ctx, doc, C.int(p.number)), nil
+}
 
-	ctm = C.fz_concat(C.fz_translate(-bounds.x0, -bounds.y0), ctm)
-	bounds = C.fz_transform_rect(bounds, ctm)
-	bbox := C.fz_round_rect(bounds)
+// reload rebuilds the cached display list after the page has been edited.
+func (p *Page) reload(pg *C.fz_page) {
+	C.fz_drop_display_list(p.ctx, p.list)
+	p.list = C.fz_new_display_list_from_page(p.ctx, pg)
+	p.bounds = C.fz_bound_page(p.ctx, pg)
+}
 
-	width := bbox.x1 - bbox.x0
-	height := bbox.y1 - bbox.y0
-	pixBbox := C.fz_make_irect(0, 0, width, height)
+// lockDocument locks the document and then the page, for edits to the
+// document's objects made through the page. Document-wide edits take the
+// locks in the same order.
+func (p *Page) lockDocument() {
+	p.docMut.Lock()
+	p.mut.Lock()
+}
 
-	pixmap := C.fz_new_pixmap_with_bbox(p.ctx, C.fz_device_rgb(p.ctx), pixBbox, nil, 1)
-	if pixmap == nil {
-		return nil, ErrCreatePixmap
-	}
+func (p *Page) unlockDocument() {
+	p.mut.Unlock()
+	p.docMut.Unlock()
+}
 
-	C.fz_clear_pixmap_with_value(p.ctx, pixmap, C.int(0xff))
-	defer C.fz_drop_pixmap(p.ctx, pixmap)
+// lockedReload is reload for document-wide edits, which do not hold p.mut.
+func (p *Page) lockedReload(pg *C.fz_page) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.reload(pg)
+}
 
-	device := C.fz_new_draw_device_with_bbox(p.ctx, ctm, pixmap, &pixBbox)
-	defer C.fz_drop_device(p.ctx, device)
+func (p *Page) Number() int { return p.number }
 
-	C.fz_enable_device_hints(p.ctx, device, C.FZ_NO_CACHE)
+func (p *Page) Bounds() gfx.Rect {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return rectFromFitz(p.bounds)
+}
 
-	C.fz_run_display_list(p.ctx, p.list, device, C.fz_identity, bounds, nil)
-	C.fz_close_device(p.ctx, device)
+// RenderImage renders a region of the page to RGB at the given scale. See
+// Render for more options.
+func (p *Page) RenderImage(region gfx.Rect, scale float64) (*image.RGBA, error) {
+	return p.RenderImageContext(context.Background(), region, scale)
+}
 
-	pixels := C.fz_pixmap_samples(p.ctx, pixmap)
-	if pixels == nil {
-		return nil, ErrPixmapSamples
+// RenderImageContext is like RenderImage but stops when ctx is done.
+func (p *Page) RenderImageContext(ctx context.Context, region gfx.Rect, scale float64) (*image.RGBA, error) {
+	img, err := p.RenderContext(ctx, RenderOptions{Region: region, DPI: 72 * scale})
+	if err != nil {
+		return nil, err
 	}
-
-	img.Pix = C.GoBytes(unsafe.Pointer(pixels), C.int(4*width*height))
-	img.Rect = image.Rect(0, 0, int(width), int(height))
-	img.Stride = 4 * img.Rect.Max.X
-
-	return img, nil
+	return img.(*image.RGBA), nil
 }
 
 func (p *Page) RunDevice(device Device) error {
+	return p.RunDeviceContext(context.Background(), device, RunOptions{})
+}
+
+// RunDeviceContext is like RunDevice but stops when ctx is done.
+func (p *Page) RunDeviceContext(ctx context.Context, device Device, opts RunOptions) error {
+	// keep the list so that edits reloading the page cannot free it, and run
+	// it on a clone so that the device may call back into the page
+	p.mut.Lock()
+	list := C.fz_keep_display_list(p.ctx, p.list)
+	fzctx := C.fz_clone_context(p.ctx)
+	p.mut.Unlock()
+	defer C.fz_drop_context(fzctx)
+	defer C.fz_drop_display_list(fzctx, list)
+
 	ref := pointer.Save(device)
 	defer pointer.Unref(ref)
 
-	fzdev := C.fz_new_go_device(p.ctx, ref)
-	defer C.fz_drop_device(p.ctx, fzdev)
+	fzdev := C.fz_new_go_device(fzctx, ref)
+	defer C.fz_drop_device(fzctx, fzdev)
 
-	C.fz_run_display_list(p.ctx, p.list, fzdev, C.fz_identity, C.fz_infinite_rect, nil)
-	C.fz_close_device(p.ctx, fzdev)
+	cookie := newCookie(ctx, opts.Progress)
+	defer cookie.close()
+
+	C.fz_run_display_list(fzctx, list, fzdev, C.fz_identity, C.fz_infinite_rect, cookie.ptr)
+	C.fz_close_device(fzctx, fzdev)
+
+	if err := cookie.err(); err != nil {
+		return err
+	}
 
 	if device.Error() != nil && device.Error() == ErrBreak {
 		return nil
@@ -116,6 +143,11 @@ func (p *Page) RunDevice(device Device) error {
 
 // RenderSVG returns svg document for given page number.
 func (p *Page) RenderSVG(scale float64) (string, error) {
+	return p.RenderSVGContext(context.Background(), scale, RunOptions{})
+}
+
+// RenderSVGContext is like RenderSVG but stops when ctx is done.
+func (p *Page) RenderSVGContext(ctx context.Context, scale float64, opts RunOptions) (string, error) {
 	p.mut.Lock()
 	defer p.mut.Unlock()
 	bounds := p.bounds
@@ -133,37 +165,44 @@ func (p *Page) RenderSVG(scale float64) (string, error) {
 	C.fz_enable_device_hints(p.ctx, device, C.FZ_NO_CACHE)
 	defer C.fz_drop_device(p.ctx, device)
 
-	var cookie C.fz_cookie
-	C.fz_run_display_list(p.ctx, p.list, device, C.fz_identity, bounds, &cookie)
+	cookie := newCookie(ctx, opts.Progress)
+	defer cookie.close()
+
+	C.fz_run_display_list(p.ctx, p.list, device, C.fz_identity, bounds, cookie.ptr)
 
 	C.fz_close_device(p.ctx, device)
 
+	if err := cookie.err(); err != nil {
+		return "", err
+	}
+
 	str := C.GoString(C.fz_string_from_buffer(p.ctx, buf))
 	return str, nil
 }
 
 // GetText returns text for page
 func (p *Page) GetText() string {
+	text, _ := p.GetTextContext(context.Background(), RunOptions{})
+	return text
+}
+
+// GetTextContext is like GetText but stops when ctx is done.
+func (p *Page) GetTextContext(ctx context.Context, opts RunOptions) (string, error) {
 	p.mut.Lock()
 	defer p.mut.Unlock()
-	bounds := p.bounds
-
-	text := C.fz_new_stext_page(p.ctx, bounds)
-	defer C.fz_drop_stext_page(p.ctx, text)
 
-	opts := C.fz_stext_options{}
-	opts.flags = 0
+	cookie := newCookie(ctx, opts.Progress)
+	defer cookie.close()
 
-	device := C.fz_new_stext_device(p.ctx, text, &opts)
-	C.fz_enable_device_hints(p.ctx, device, C.FZ_NO_CACHE)
-	defer C.fz_drop_device(p.ctx, device)
+	text := newStextPage(p.ctx, p.list, p.bounds, 0, cookie.ptr)
+	defer C.fz_drop_stext_page(p.ctx, text)
 
-	var cookie C.fz_cookie
-	C.fz_run_display_list(p.ctx, p.list, device, C.fz_identity, bounds, &cookie)
-	C.fz_close_device(p.ctx, device)
+	if err := cookie.err(); err != nil {
+		return "", err
+	}
 
 	buf := C.fz_new_buffer_from_stext_page(p.ctx, text)
 	defer C.fz_drop_buffer(p.ctx, buf)
 
-	return C.GoString(C.fz_string_from_buffer(p.ctx, buf))
+	return C.GoString(C.fz_string_from_buffer(p.ctx, buf)), nil
 }
@@ -0,0 +1,80 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"github.com/bryanmatteson/gfx"
+)
+
+// RedactImageMethod selects how images under a redaction are handled.
+type RedactImageMethod int
+
+const (
+	// RedactImageNone leaves images untouched.
+	RedactImageNone RedactImageMethod = C.PDF_REDACT_IMAGE_NONE
+	// RedactImageRemove removes any image that overlaps a redaction.
+	RedactImageRemove RedactImageMethod = C.PDF_REDACT_IMAGE_REMOVE
+	// RedactImagePixels blanks out the covered pixels of overlapping images.
+	RedactImagePixels RedactImageMethod = C.PDF_REDACT_IMAGE_PIXELS
+)
+
+// RedactOptions controls how redactions are applied.
+type RedactOptions struct {
+	// BlackBoxes fills each redacted region with black.
+	BlackBoxes bool
+	Images     RedactImageMethod
+}
+
+// DefaultRedactOptions draws black boxes and blanks covered image pixels.
+func DefaultRedactOptions() RedactOptions {
+	return RedactOptions{BlackBoxes: true, Images: RedactImagePixels}
+}
+
+// RedactRegion is an area of the page to redact, either a rectangle or a set
+// of quads such as those returned by text search. If Quads is set the
+// rectangle defaults to their union.
+type RedactRegion struct {
+	Rect  gfx.Rect
+	Quads []gfx.Quad
+}
+
+// Redact marks regions for redaction and applies every redaction on the
+// page, removing the text, vector graphics and image content underneath
+// rather than just covering it. Redactions already present on the page are
+// applied as well.
+func (p *Page) Redact(regions []RedactRegion, opts RedactOptions) (err error) {
+	p.lockDocument()
+	defer p.unlockDocument()
+
+	pg, err := p.loadPDFPage()
+	if err != nil {
+		return err
+	}
+	defer C.fz_drop_page(p.ctx, &pg.super)
+	defer catch(&err)
+
+	for _, region := range regions {
+		rect := rectToFitz(region.Rect)
+		if region.Rect.IsEmpty() && len(region.Quads) > 0 {
+			rect = C.fz_rect_from_quad(quadToFitz(region.Quads[0]))
+			for _, q := range region.Quads[1:] {
+				rect = C.fz_union_rect(rect, C.fz_rect_from_quad(quadToFitz(q)))
+			}
+		}
+
+		annot := C.pdf_create_annot(p.ctx, pg, C.PDF_ANNOT_REDACT)
+		C.pdf_set_annot_rect(p.ctx, annot, rect)
+		setAnnotQuads(p.ctx, annot, region.Quads)
+		C.pdf_drop_annot(p.ctx, annot)
+	}
+
+	options := C.pdf_redact_options{image_method: C.int(opts.Images)}
+	if opts.BlackBoxes {
+		options.black_boxes = 1
+	}
+
+	C.pdf_redact_page(p.ctx, C.pdf_specifics(p.ctx, p.doc), pg, &options)
+
+	p.reload(&pg.super)
+	return nil
+}
@@ -0,0 +1,48 @@
+package fitz_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bryanmatteson/fitz"
+)
+
+func TestRedact(t *testing.T) {
+	doc := openSample(t)
+	pg := loadPage(t, doc, 0)
+
+	hits, err := pg.Search("12345", fitz.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 {
+		t.Fatalf("Search() = %d hits, want 1", len(hits))
+	}
+
+	regions := []fitz.RedactRegion{
+		{Quads: hits[0].Quads},
+		// Hello World, whose baseline is 72pt from the top of the page
+		{Rect: rectWH(70, 58, 100, 20)},
+	}
+	if err := pg.Redact(regions, fitz.DefaultRedactOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := loadPage(t, reopen(t, doc), 0)
+	text := saved.GetText()
+	for _, gone := range []string{"12345", "Hello", "World"} {
+		if strings.Contains(text, gone) {
+			t.Errorf("GetText() after Redact = %q, want %s removed", text, gone)
+		}
+	}
+	for _, kept := range []string{"Account number", "quick brown fox"} {
+		if !strings.Contains(text, kept) {
+			t.Errorf("GetText() after Redact = %q, want %s kept", text, kept)
+		}
+	}
+
+	// applied redactions leave no annotations behind
+	if annots, err := saved.Annotations(); err != nil || len(annots) != 0 {
+		t.Errorf("Annotations() after Redact = %d annotations, %v, want none", len(annots), err)
+	}
+}
@@ -0,0 +1,259 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"unsafe"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// OutputColorspace is the colorspace of a rendered image.
+type OutputColorspace int
+
+const (
+	// ColorspaceRGB renders to an *image.RGBA.
+	ColorspaceRGB OutputColorspace = iota
+	// ColorspaceGray renders to an *image.Gray.
+	ColorspaceGray
+	// ColorspaceCMYK renders to an *image.CMYK.
+	ColorspaceCMYK
+)
+
+func (cs OutputColorspace) fitz(ctx *C.fz_context) *C.fz_colorspace {
+	switch cs {
+	case ColorspaceGray:
+		return C.fz_device_gray(ctx)
+	case ColorspaceCMYK:
+		return C.fz_device_cmyk(ctx)
+	default:
+		return C.fz_device_rgb(ctx)
+	}
+}
+
+// AntiAlias is a level of anti-aliasing.
+type AntiAlias int
+
+const (
+	// AntiAliasDefault leaves the level of the context unchanged.
+	AntiAliasDefault AntiAlias = iota
+	AntiAliasNone
+	AntiAliasLow
+	AntiAliasMedium
+	AntiAliasHigh
+)
+
+// bits returns the number of bits of anti-aliasing for fz_set_aa_level.
+func (a AntiAlias) bits() C.int {
+	switch a {
+	case AntiAliasLow:
+		return 2
+	case AntiAliasMedium:
+		return 4
+	case AntiAliasHigh:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// RenderOptions controls rasterization of a page.
+type RenderOptions struct {
+	// Region limits rendering to part of the page, in page coordinates. If
+	// empty the whole page is rendered.
+	Region gfx.Rect
+	// DPI is the resolution. Defaults to 72, one pixel per point.
+	DPI float64
+	// Width and Height fit the region into a box of that many pixels,
+	// keeping its aspect ratio, and take precedence over DPI. Either may be
+	// zero to fit the other dimension only.
+	Width, Height int
+	// Rotation is the clockwise rotation in degrees.
+	Rotation float64
+	// Colorspace selects the type of image returned. Defaults to RGB.
+	Colorspace OutputColorspace
+	// Alpha leaves the background transparent. It applies to RGB output
+	// only; gray and CMYK images are always opaque.
+	Alpha bool
+	// Background is the color the page is drawn on when Alpha is false.
+	// Defaults to white.
+	Background color.Color
+	// TextAntiAlias and GraphicsAntiAlias set the anti-aliasing of text and
+	// of other graphics.
+	TextAntiAlias     AntiAlias
+	GraphicsAntiAlias AntiAlias
+	// MinLineWidth is the thinnest a stroke is drawn, in pixels. Zero keeps
+	// the default.
+	MinLineWidth float64
+	// Progress, if set, is called from another goroutine with the progress
+	// of the render. Tiled renders report each tile separately.
+	Progress ProgressFunc
+}
+
+// transform returns the area of the page to render and the transform from
+// page coordinates to pixels.
+func (o RenderOptions) transform(bounds C.fz_rect) (area C.fz_rect, ctm C.fz_matrix) {
+	area = bounds
+	if !o.Region.IsEmpty() {
+		area = C.fz_intersect_rect(bounds, rectToFitz(o.Region))
+	}
+
+	rotate := C.fz_rotate(C.float(o.Rotation))
+
+	scale := 1.0
+	if o.DPI > 0 {
+		scale = o.DPI / 72
+	}
+
+	if o.Width > 0 || o.Height > 0 {
+		r := C.fz_transform_rect(area, rotate)
+		w, h := float64(r.x1-r.x0), float64(r.y1-r.y0)
+
+		fit := math.Inf(1)
+		if o.Width > 0 && w > 0 {
+			fit = float64(o.Width) / w
+		}
+		if o.Height > 0 && h > 0 {
+			fit = math.Min(fit, float64(o.Height)/h)
+		}
+		if !math.IsInf(fit, 1) {
+			scale = fit
+		}
+	}
+
+	return area, C.fz_concat(C.fz_scale(C.float(scale), C.float(scale)), rotate)
+}
+
+// Render rasterizes the page. The concrete type of the image depends on the
+// colorspace: *image.RGBA, *image.Gray or *image.CMYK.
+func (p *Page) Render(opts RenderOptions) (image.Image, error) {
+	return p.RenderContext(context.Background(), opts)
+}
+
+// RenderContext is like Render but stops when ctx is done.
+func (p *Page) RenderContext(ctx context.Context, opts RenderOptions) (image.Image, error) {
+	ctm, bbox := p.renderTransform(opts)
+	return p.renderRect(ctx, ctm, bbox, opts, image.Point{})
+}
+
+// renderTransform returns the transform from page coordinates to pixels and
+// the pixel bounds of the page image described by opts.
+func (p *Page) renderTransform(opts RenderOptions) (C.fz_matrix, C.fz_irect) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	area, ctm := opts.transform(p.bounds)
+	return ctm, C.fz_round_rect(C.fz_transform_rect(area, ctm))
+}
+
+// renderRect renders the pixels in bbox of the page image produced by ctm.
+// The image returned is positioned at origin.
+func (p *Page) renderRect(ctx context.Context, ctm C.fz_matrix, bbox C.fz_irect, opts RenderOptions, origin image.Point) (img image.Image, err error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	defer catch(&err)
+
+	cookie := newCookie(ctx, opts.Progress)
+	defer cookie.close()
+
+	pixmap := renderImagePixmap(p.ctx, p.list, ctm, bbox, opts, cookie.ptr)
+	if pixmap == nil {
+		return nil, ErrCreatePixmap
+	}
+	defer C.fz_drop_pixmap(p.ctx, pixmap)
+
+	if err := cookie.err(); err != nil {
+		return nil, err
+	}
+
+	return imageFromPixmap(p.ctx, pixmap, opts.Colorspace, origin)
+}
+
+// renderImagePixmap renders a pixmap laid out the way imageFromPixmap
+// expects. RGB pixmaps always carry alpha so they map directly onto
+// image.RGBA.
+func renderImagePixmap(ctx *C.fz_context, list *C.fz_display_list, ctm C.fz_matrix, bbox C.fz_irect, opts RenderOptions, cookie *C.fz_cookie) *C.fz_pixmap {
+	return renderPixmap(ctx, list, ctm, bbox, opts.Colorspace.fitz(ctx), opts.Colorspace == ColorspaceRGB, opts, cookie)
+}
+
+// renderPixmap draws the part of the display list that ctm maps into bbox
+// on a new pixmap in colorspace cs, with an alpha channel if alpha is set.
+// The caller must drop the result.
+func renderPixmap(ctx *C.fz_context, list *C.fz_display_list, ctm C.fz_matrix, bbox C.fz_irect, cs *C.fz_colorspace, alpha bool, opts RenderOptions, cookie *C.fz_cookie) *C.fz_pixmap {
+	var withAlpha C.int
+	if alpha {
+		withAlpha = 1
+	}
+
+	pixmap := C.fz_new_pixmap_with_bbox(ctx, cs, bbox, nil, withAlpha)
+	if pixmap == nil {
+		return nil
+	}
+
+	if opts.Alpha && alpha {
+		C.fz_clear_pixmap(ctx, pixmap)
+	} else {
+		background := opts.Background
+		if background == nil {
+			background = color.White
+		}
+		c := color.NRGBAModel.Convert(background).(color.NRGBA)
+		rgb := [3]C.float{C.float(c.R) / 255, C.float(c.G) / 255, C.float(c.B) / 255}
+		C.fz_fill_pixmap_with_color(ctx, pixmap, C.fz_device_rgb(ctx), &rgb[0], C.fz_default_color_params)
+	}
+
+	// anti-aliasing and line width are context settings, so draw with a
+	// clone to keep them from other users of the context
+	if opts.TextAntiAlias != AntiAliasDefault || opts.GraphicsAntiAlias != AntiAliasDefault || opts.MinLineWidth > 0 {
+		ctx = C.fz_clone_context(ctx)
+		defer C.fz_drop_context(ctx)
+	}
+	if opts.TextAntiAlias != AntiAliasDefault {
+		C.fz_set_text_aa_level(ctx, opts.TextAntiAlias.bits())
+	}
+	if opts.GraphicsAntiAlias != AntiAliasDefault {
+		C.fz_set_graphics_aa_level(ctx, opts.GraphicsAntiAlias.bits())
+	}
+	if opts.MinLineWidth > 0 {
+		C.fz_set_graphics_min_line_width(ctx, C.float(opts.MinLineWidth))
+	}
+
+	device := C.fz_new_draw_device(ctx, C.fz_identity, pixmap)
+	defer C.fz_drop_device(ctx, device)
+
+	C.fz_enable_device_hints(ctx, device, C.FZ_NO_CACHE)
+
+	C.fz_run_display_list(ctx, list, device, ctm, C.fz_rect_from_irect(bbox), cookie)
+	C.fz_close_device(ctx, device)
+
+	return pixmap
+}
+
+// imageFromPixmap copies the samples of a pixmap rendered by renderPixmap
+// into a Go image whose bounds start at origin.
+func imageFromPixmap(ctx *C.fz_context, pixmap *C.fz_pixmap, cs OutputColorspace, origin image.Point) (image.Image, error) {
+	width := int(C.fz_pixmap_width(ctx, pixmap))
+	height := int(C.fz_pixmap_height(ctx, pixmap))
+	stride := int(C.fz_pixmap_stride(ctx, pixmap))
+
+	samples := C.fz_pixmap_samples(ctx, pixmap)
+	if samples == nil {
+		return nil, ErrPixmapSamples
+	}
+
+	pix := C.GoBytes(unsafe.Pointer(samples), C.int(stride*height))
+	rect := image.Rect(0, 0, width, height).Add(origin)
+
+	switch cs {
+	case ColorspaceGray:
+		return &image.Gray{Pix: pix, Stride: stride, Rect: rect}, nil
+	case ColorspaceCMYK:
+		return &image.CMYK{Pix: pix, Stride: stride, Rect: rect}, nil
+	default:
+		return &image.RGBA{Pix: pix, Stride: stride, Rect: rect}, nil
+	}
+}
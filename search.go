@@ -0,0 +1,300 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"context"
+	"runtime"
+	"sync"
+	"unicode"
+	"unsafe"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// SearchOptions controls text search.
+type SearchOptions struct {
+	// MatchCase disables case folding.
+	MatchCase bool
+	// ExactWhitespace requires whitespace to match exactly. By default any
+	// run of whitespace, including line breaks, matches any other.
+	ExactWhitespace bool
+}
+
+// SearchHit is a single match. A hit spanning several lines has one quad per
+// line.
+type SearchHit struct {
+	Page  int
+	Quads []gfx.Quad
+}
+
+// SearchResult holds the hits found on one page by Document.Search.
+type SearchResult struct {
+	Page int
+	Hits []SearchHit
+	Err  error
+}
+
+// Search finds every occurrence of needle on the page. Matching is done by
+// fz_search_stext_page, which folds ASCII case and lets any run of
+// whitespace, including line breaks, match any other. Its quads are grouped
+// into hits, and each hit is then checked against needle under the rules of
+// opts, so MatchCase and ExactWhitespace only narrow its matches.
+func (p *Page) Search(needle string, opts SearchOptions) (hits []SearchHit, err error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	return searchPage(p.ctx, p, needle, opts)
+}
+
+// Search searches every page of the document concurrently and streams one
+// result per page, in no particular order. The channel is closed once all
+// pages have been searched or ctx is done. Pages without hits are not
+// reported. A cancelled search is not reported either, so callers must
+// check ctx.Err() to tell it apart from one that completed.
+func (d *Document) Search(ctx context.Context, needle string, opts SearchOptions) <-chan SearchResult {
+	results := make(chan SearchResult)
+	pages := make(chan int)
+
+	go func() {
+		defer close(pages)
+		for i, n := 0, d.NumPages(); i < n; i++ {
+			select {
+			case pages <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// every goroutine needs its own context
+			fzctx := C.fz_clone_context(d.ctx)
+			defer C.fz_drop_context(fzctx)
+
+			for num := range pages {
+				page, err := d.LoadPage(num)
+				var hits []SearchHit
+				if err == nil {
+					page.mut.Lock()
+					hits, err = searchPage(fzctx, page, needle, opts)
+					page.mut.Unlock()
+				}
+				if err == nil && len(hits) == 0 {
+					continue
+				}
+
+				select {
+				case results <- SearchResult{Page: num, Hits: hits, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// searchChar is a character of the searched text and the index of its line
+// on the page.
+type searchChar struct {
+	r    rune
+	line int
+	quad *gfx.Quad
+}
+
+func searchPage(ctx *C.fz_context, p *Page, needle string, opts SearchOptions) (hits []SearchHit, err error) {
+	defer catch(&err)
+
+	pattern := normalizeSearch([]rune(needle), opts)
+	length := 0
+	for _, r := range pattern {
+		if !unicode.IsSpace(r) {
+			length++
+		}
+	}
+	if length == 0 {
+		return nil, nil
+	}
+
+	text := newStextPage(ctx, p.list, p.bounds, 0, nil)
+	defer C.fz_drop_stext_page(ctx, text)
+
+	chars := searchText(text)
+
+	// fz_search_stext_page matches every character of the needle other
+	// than whitespace with exactly one character of the page, so a hit is
+	// complete once its quads cover that many
+	var hit []searchChar
+	var quads []gfx.Quad
+	next := 0
+	for _, q := range searchQuads(ctx, text, needle) {
+		var covered []searchChar
+		covered, next = charsInQuad(chars, next, q)
+		hit = append(hit, covered...)
+		quads = append(quads, quadFromFitz(q))
+
+		n := 0
+		for _, c := range hit {
+			if !unicode.IsSpace(c.r) {
+				n++
+			}
+		}
+		if n < length {
+			continue
+		}
+
+		if matchHit(hit, pattern, opts) {
+			hits = append(hits, SearchHit{Page: p.number, Quads: quads})
+		}
+		hit, quads = nil, nil
+	}
+
+	return hits, nil
+}
+
+// searchQuads returns the quads found by fz_search_stext_page, growing the
+// buffer until all of them fit.
+func searchQuads(ctx *C.fz_context, text *C.fz_stext_page, needle string) []C.fz_quad {
+	cneedle := C.CString(needle)
+	defer C.free(unsafe.Pointer(cneedle))
+
+	for max := 256; ; max *= 2 {
+		quads := make([]C.fz_quad, max)
+		n := int(C.fz_search_stext_page(ctx, text, cneedle, &quads[0], C.int(max)))
+		if n < max {
+			return quads[:n]
+		}
+	}
+}
+
+func searchText(text *C.fz_stext_page) []searchChar {
+	var chars []searchChar
+	line := 0
+
+	for block := text.first_block; block != nil; block = block.next {
+		for ln := C.fzgo_stext_block_first_line(block); ln != nil; ln = ln.next {
+			for ch := ln.first_char; ch != nil; ch = ch.next {
+				q := quadFromFitz(ch.quad)
+				chars = append(chars, searchChar{r: rune(ch.c), line: line, quad: &q})
+			}
+			line++
+		}
+	}
+
+	return chars
+}
+
+// charsInQuad returns the run of characters from start on whose centres lie
+// in q, and the index following it.
+func charsInQuad(chars []searchChar, start int, q C.fz_quad) ([]searchChar, int) {
+	first := -1
+	for i := start; i < len(chars); i++ {
+		c := chars[i].quad
+		centre := C.fz_make_point(
+			C.float((c.TopLeft.X+c.TopRight.X+c.BottomLeft.X+c.BottomRight.X)/4),
+			C.float((c.TopLeft.Y+c.TopRight.Y+c.BottomLeft.Y+c.BottomRight.Y)/4),
+		)
+		inside := C.fz_is_point_inside_quad(centre, q) != 0
+		switch {
+		case inside && first < 0:
+			first = i
+		case !inside && first >= 0:
+			return chars[first:i], i
+		}
+	}
+	if first < 0 {
+		return nil, start
+	}
+	return chars[first:], len(chars)
+}
+
+// matchHit reports whether the characters of a hit equal pattern under the
+// case and whitespace rules of opts. Line breaks count as spaces.
+func matchHit(hit []searchChar, pattern []rune, opts SearchOptions) bool {
+	var chars []searchChar
+	for i, c := range hit {
+		if i > 0 && c.line != hit[i-1].line {
+			chars = append(chars, searchChar{r: ' ', line: c.line})
+		}
+		chars = append(chars, c)
+	}
+
+	chars = normalizeChars(chars, opts)
+	if len(chars) != len(pattern) {
+		return false
+	}
+	for i, r := range pattern {
+		if chars[i].r != r {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeSearch(needle []rune, opts SearchOptions) []rune {
+	chars := make([]searchChar, len(needle))
+	for i, r := range needle {
+		chars[i] = searchChar{r: r}
+	}
+
+	chars = normalizeChars(chars, opts)
+	runes := make([]rune, len(chars))
+	for i, c := range chars {
+		runes[i] = c.r
+	}
+	return runes
+}
+
+// normalizeChars folds case and collapses whitespace as requested by opts.
+func normalizeChars(chars []searchChar, opts SearchOptions) []searchChar {
+	out := chars[:0]
+	for _, c := range chars {
+		if !opts.MatchCase {
+			c.r = unicode.ToLower(c.r)
+		}
+		if !opts.ExactWhitespace && unicode.IsSpace(c.r) {
+			c.r = ' '
+			if len(out) > 0 && out[len(out)-1].r == ' ' {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// hitQuads merges the quads of the matched characters into one quad per
+// line.
+func hitQuads(chars []searchChar) []gfx.Quad {
+	var quads []gfx.Quad
+	line := -1
+
+	for _, c := range chars {
+		if c.quad == nil {
+			continue
+		}
+		if c.line != line || len(quads) == 0 {
+			quads = append(quads, *c.quad)
+			line = c.line
+			continue
+		}
+
+		last := &quads[len(quads)-1]
+		last.TopRight = c.quad.TopRight
+		last.BottomRight = c.quad.BottomRight
+	}
+
+	return quads
+}
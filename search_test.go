@@ -0,0 +1,71 @@
+package fitz_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/bryanmatteson/fitz"
+)
+
+func TestPageSearch(t *testing.T) {
+	pg := loadPage(t, openSample(t), 0)
+
+	tests := []struct {
+		needle string
+		opts   fitz.SearchOptions
+		hits   int
+	}{
+		{"hello world", fitz.SearchOptions{}, 1},
+		{"hello world", fitz.SearchOptions{MatchCase: true}, 0},
+		{"Hello World", fitz.SearchOptions{MatchCase: true}, 1},
+		{"Hello \t World", fitz.SearchOptions{}, 1},
+		{"Hello \t World", fitz.SearchOptions{ExactWhitespace: true}, 0},
+		{"quick brown", fitz.SearchOptions{}, 1},
+		{"o", fitz.SearchOptions{}, 7},
+		{"12345", fitz.SearchOptions{}, 1},
+		{"missing", fitz.SearchOptions{}, 0},
+		{"", fitz.SearchOptions{}, 0},
+	}
+
+	for _, tt := range tests {
+		hits, err := pg.Search(tt.needle, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) != tt.hits {
+			t.Errorf("Search(%q, %+v) = %d hits, want %d", tt.needle, tt.opts, len(hits), tt.hits)
+		}
+	}
+
+	hits, err := pg.Search("Hello World", fitz.SearchOptions{})
+	if err != nil || len(hits) != 1 {
+		t.Fatalf("Search() = %v, %v, want 1 hit", hits, err)
+	}
+	if hit := hits[0]; hit.Page != 0 || len(hit.Quads) != 1 {
+		t.Errorf("Search() = %+v, want one quad on page 0", hit)
+	} else if q := hit.Quads[0]; math.Abs(q.BottomLeft.X-72) > 1 || q.TopLeft.Y > 72 || q.BottomLeft.Y < 72 {
+		t.Errorf("Search() quad = %v, want it around the baseline at 72,72", q)
+	}
+}
+
+func TestDocumentSearch(t *testing.T) {
+	doc := openSample(t)
+
+	hits := make(map[int]int)
+	for result := range doc.Search(context.Background(), "hello", fitz.SearchOptions{}) {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		hits[result.Page] += len(result.Hits)
+	}
+	if len(hits) != 2 || hits[0] != 1 || hits[1] != 1 {
+		t.Errorf("Search() hits by page = %v, want one on each page", hits)
+	}
+
+	// a cancelled search still closes its channel
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for range doc.Search(ctx, "hello", fitz.SearchOptions{}) {
+	}
+}
@@ -0,0 +1,68 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import "unsafe"
+
+// Auth describes which password, if any, was used to unlock a document.
+type Auth int
+
+const (
+	// AuthNone is set when the document is not protected by a password.
+	AuthNone Auth = 1 << iota
+	// AuthUser is set when the user password was matched.
+	AuthUser
+	// AuthOwner is set when the owner password was matched.
+	AuthOwner
+)
+
+func (a Auth) User() bool  { return a&AuthUser != 0 }
+func (a Auth) Owner() bool { return a&AuthOwner != 0 }
+
+// Permissions are the access rights granted by the document's encryption
+// dictionary for the password used to open it.
+type Permissions struct {
+	Print    bool
+	Copy     bool
+	Modify   bool
+	Annotate bool
+}
+
+// NeedsPassword reports whether the document is encrypted with a non-blank
+// password.
+func (d *Document) NeedsPassword() bool {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	return C.fz_needs_password(d.ctx, d.native) != 0
+}
+
+// Authenticate tries password against the document. Authenticating with the
+// owner password of a document opened with the user password elevates its
+// permissions. ErrInvalidPassword is returned if neither password matched.
+func (d *Document) Authenticate(password string) (Auth, error) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	pw := C.CString(password)
+	defer C.free(unsafe.Pointer(pw))
+
+	ret := Auth(C.fz_authenticate_password(d.ctx, d.native, pw))
+	if ret == 0 {
+		return 0, ErrInvalidPassword
+	}
+
+	return ret, nil
+}
+
+// Permissions returns the permissions granted to the current password.
+func (d *Document) Permissions() Permissions {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	return Permissions{
+		Print:    C.fz_has_permission(d.ctx, d.native, C.FZ_PERMISSION_PRINT) != 0,
+		Copy:     C.fz_has_permission(d.ctx, d.native, C.FZ_PERMISSION_COPY) != 0,
+		Modify:   C.fz_has_permission(d.ctx, d.native, C.FZ_PERMISSION_EDIT) != 0,
+		Annotate: C.fz_has_permission(d.ctx, d.native, C.FZ_PERMISSION_ANNOTATE) != 0,
+	}
+}
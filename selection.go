@@ -0,0 +1,92 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"unsafe"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// SelectMode controls how a selection snaps to the text.
+type SelectMode int
+
+const (
+	SelectChars SelectMode = C.FZ_SELECT_CHARS
+	SelectWords SelectMode = C.FZ_SELECT_WORDS
+	SelectLines SelectMode = C.FZ_SELECT_LINES
+)
+
+// Selection is selected text and the quads that highlight it, one per line.
+type Selection struct {
+	Text  string
+	Quads []gfx.Quad
+}
+
+// SelectText selects the text between a and b in reading order, snapping
+// both ends according to mode.
+func (p *Page) SelectText(a, b gfx.Point, mode SelectMode) (sel Selection, err error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	defer catch(&err)
+
+	text := p.newStextPage(0)
+	defer C.fz_drop_stext_page(p.ctx, text)
+
+	pa, pb := pointToFitz(a), pointToFitz(b)
+	C.fz_snap_selection(p.ctx, text, &pa, &pb, C.int(mode))
+
+	str := C.fz_copy_selection(p.ctx, text, pa, pb, 0)
+	defer C.fz_free(p.ctx, unsafe.Pointer(str))
+
+	sel.Text = C.GoString(str)
+
+	for max := 64; ; max *= 2 {
+		quads := make([]C.fz_quad, max)
+		n := int(C.fz_highlight_selection(p.ctx, text, pa, pb, &quads[0], C.int(max)))
+		if n < max {
+			sel.Quads = make([]gfx.Quad, n)
+			for i := range sel.Quads {
+				sel.Quads[i] = quadFromFitz(quads[i])
+			}
+			break
+		}
+	}
+
+	return sel, nil
+}
+
+// TextInRect selects the characters whose centre lies inside r.
+func (p *Page) TextInRect(r gfx.Rect) (sel Selection, err error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	defer catch(&err)
+
+	text := p.newStextPage(0)
+	defer C.fz_drop_stext_page(p.ctx, text)
+
+	area := rectToFitz(r)
+	str := C.fz_copy_rectangle(p.ctx, text, area, 0)
+	defer C.fz_free(p.ctx, unsafe.Pointer(str))
+
+	sel.Text = C.GoString(str)
+
+	var chars []searchChar
+	line := 0
+	for block := text.first_block; block != nil; block = block.next {
+		for ln := C.fzgo_stext_block_first_line(block); ln != nil; ln = ln.next {
+			for ch := ln.first_char; ch != nil; ch = ch.next {
+				bbox := C.fz_rect_from_quad(ch.quad)
+				center := C.fz_make_point((bbox.x0+bbox.x1)/2, (bbox.y0+bbox.y1)/2)
+				if C.fz_is_point_inside_rect(center, area) != 0 {
+					q := quadFromFitz(ch.quad)
+					chars = append(chars, searchChar{r: rune(ch.c), line: line, quad: &q})
+				}
+			}
+			line++
+		}
+	}
+	sel.Quads = hitQuads(chars)
+
+	return sel, nil
+}
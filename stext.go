@@ -0,0 +1,281 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"image"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// StextFlags control how structured text is extracted.
+type StextFlags int
+
+const (
+	// StextPreserveLigatures keeps ligatures as single characters instead
+	// of expanding them.
+	StextPreserveLigatures StextFlags = C.FZ_STEXT_PRESERVE_LIGATURES
+	// StextPreserveWhitespace keeps whitespace as is instead of converting
+	// it to plain spaces.
+	StextPreserveWhitespace StextFlags = C.FZ_STEXT_PRESERVE_WHITESPACE
+	// StextPreserveImages adds image blocks for the images on the page.
+	StextPreserveImages StextFlags = C.FZ_STEXT_PRESERVE_IMAGES
+	// StextInhibitSpaces stops spaces being inserted for gaps between
+	// characters.
+	StextInhibitSpaces StextFlags = C.FZ_STEXT_INHIBIT_SPACES
+	// StextDehyphenate joins words hyphenated at the end of a line.
+	StextDehyphenate StextFlags = C.FZ_STEXT_DEHYPHENATE
+	// StextPreserveSpans keeps spans on the same line in separate lines.
+	StextPreserveSpans StextFlags = C.FZ_STEXT_PRESERVE_SPANS
+	// StextMediaboxClip drops characters entirely outside the page.
+	StextMediaboxClip StextFlags = C.FZ_STEXT_MEDIABOX_CLIP
+)
+
+// StructuredTextOptions controls Page.StructuredText.
+type StructuredTextOptions struct {
+	Flags StextFlags
+}
+
+// BlockType is the kind of a structured text block.
+type BlockType int
+
+const (
+	BlockText  BlockType = C.FZ_STEXT_BLOCK_TEXT
+	BlockImage BlockType = C.FZ_STEXT_BLOCK_IMAGE
+)
+
+// FontFlags describe the style of a font.
+type FontFlags int
+
+const (
+	FontBold FontFlags = 1 << iota
+	FontItalic
+	FontSerif
+	FontMonospaced
+)
+
+// StructuredText is the text of a page organised into blocks, lines, runs
+// and characters.
+type StructuredText struct {
+	Bounds gfx.Rect
+	Blocks []*TextBlock
+}
+
+// TextBlock is a paragraph of text or an image. Lines is set for text
+// blocks, Image and Transform for image blocks.
+type TextBlock struct {
+	Type      BlockType
+	Bounds    gfx.Rect
+	Lines     []*TextLine
+	Image     image.Image
+	Transform gfx.Matrix
+}
+
+// TextLine is a sequence of runs sharing a baseline.
+type TextLine struct {
+	Bounds gfx.Rect
+	WMode  int
+	// Dir is the normalized direction of the baseline.
+	Dir  gfx.Point
+	Runs []*TextRun
+}
+
+// TextRun is a span of consecutive characters in a line that share font,
+// size and color.
+type TextRun struct {
+	Bounds    gfx.Rect
+	Font      string
+	FontSize  float64
+	FontFlags FontFlags
+	Color     color.NRGBA
+	Chars     []TextChar
+}
+
+// TextChar is a single character and its position.
+type TextChar struct {
+	Rune   rune
+	Origin gfx.Point
+	Quad   gfx.Quad
+}
+
+func (b *TextBlock) String() string {
+	lines := make([]string, len(b.Lines))
+	for i, line := range b.Lines {
+		lines[i] = line.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (l *TextLine) String() string {
+	var builder strings.Builder
+	for _, run := range l.Runs {
+		builder.WriteString(run.String())
+	}
+	return builder.String()
+}
+
+func (r *TextRun) String() string {
+	var builder strings.Builder
+	for _, c := range r.Chars {
+		builder.WriteRune(c.Rune)
+	}
+	return builder.String()
+}
+
+// StructuredText extracts the text of the page with its geometry and style.
+func (p *Page) StructuredText(opts StructuredTextOptions) (st *StructuredText, err error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	defer catch(&err)
+
+	text := p.newStextPage(opts.Flags)
+	defer C.fz_drop_stext_page(p.ctx, text)
+
+	return structuredTextFromFitz(p.ctx, text), nil
+}
+
+// TextFormat is a serialization of structured text.
+type TextFormat int
+
+const (
+	// TextPlain is plain UTF-8 text, as returned by GetText.
+	TextPlain TextFormat = iota
+	// TextHTML is HTML with absolutely positioned lines.
+	TextHTML
+	// TextXHTML is semantic XHTML with paragraphs and headings.
+	TextXHTML
+	// TextXML is the mupdf stext XML format with per-character detail.
+	TextXML
+	// TextJSON is the mupdf stext JSON format.
+	TextJSON
+)
+
+// WriteText writes the structured text of the page to w in the given
+// format.
+func (p *Page) WriteText(w io.Writer, format TextFormat, opts StructuredTextOptions) (err error) {
+	if format < TextPlain || format > TextJSON {
+		return ErrUnknownFormat
+	}
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	defer catch(&err)
+
+	text := p.newStextPage(opts.Flags)
+	defer C.fz_drop_stext_page(p.ctx, text)
+
+	output, writer := newStreamOutputForWriter(p.ctx, 8192, w)
+	defer C.fz_drop_output(p.ctx, output)
+	defer writer.catch(&err)
+
+	id := C.int(p.number + 1)
+	switch format {
+	case TextPlain:
+		C.fz_print_stext_page_as_text(p.ctx, output, text)
+	case TextHTML:
+		C.fz_print_stext_header_as_html(p.ctx, output)
+		C.fz_print_stext_page_as_html(p.ctx, output, text, id)
+		C.fz_print_stext_trailer_as_html(p.ctx, output)
+	case TextXHTML:
+		C.fz_print_stext_header_as_xhtml(p.ctx, output)
+		C.fz_print_stext_page_as_xhtml(p.ctx, output, text, id)
+		C.fz_print_stext_trailer_as_xhtml(p.ctx, output)
+	case TextXML:
+		C.fz_print_stext_page_as_xml(p.ctx, output, text, id)
+	case TextJSON:
+		C.fz_print_stext_page_as_json(p.ctx, output, text, 1)
+	}
+
+	C.fz_close_output(p.ctx, output)
+	return writer.err
+}
+
+// newStextPage runs the page through a structured text device. The caller
+// must hold p.mut and drop the result.
+func (p *Page) newStextPage(flags StextFlags) *C.fz_stext_page {
+	return newStextPage(p.ctx, p.list, p.bounds, flags, nil)
+}
+
+func newStextPage(ctx *C.fz_context, list *C.fz_display_list, bounds C.fz_rect, flags StextFlags, cookie *C.fz_cookie) *C.fz_stext_page {
+	text := C.fz_new_stext_page(ctx, bounds)
+
+	opts := C.fz_stext_options{flags: C.int(flags)}
+	device := C.fz_new_stext_device(ctx, text, &opts)
+	C.fz_enable_device_hints(ctx, device, C.FZ_NO_CACHE)
+	defer C.fz_drop_device(ctx, device)
+
+	C.fz_run_display_list(ctx, list, device, C.fz_identity, bounds, cookie)
+	C.fz_close_device(ctx, device)
+
+	return text
+}
+
+func structuredTextFromFitz(ctx *C.fz_context, text *C.fz_stext_page) *StructuredText {
+	st := &StructuredText{Bounds: rectFromFitz(text.mediabox)}
+	for block := text.first_block; block != nil; block = block.next {
+		st.Blocks = append(st.Blocks, textBlockFromFitz(ctx, block))
+	}
+	return st
+}
+
+func textBlockFromFitz(ctx *C.fz_context, block *C.fz_stext_block) *TextBlock {
+	b := &TextBlock{Type: BlockType(block._type), Bounds: rectFromFitz(block.bbox)}
+
+	if b.Type == BlockImage {
+		b.Transform = matrixFromFitz(C.fzgo_stext_block_transform(block))
+		if img := C.fzgo_stext_block_image(block); img != nil {
+			b.Image = getImage(ctx, img, C.fz_default_color_params)
+		}
+		return b
+	}
+
+	for line := C.fzgo_stext_block_first_line(block); line != nil; line = line.next {
+		l := &TextLine{Bounds: rectFromFitz(line.bbox), WMode: int(line.wmode), Dir: pointFromFitz(line.dir)}
+
+		var run *TextRun
+		for ch := line.first_char; ch != nil; ch = ch.next {
+			if run == nil || !sameStyle(ctx, run, ch) {
+				run = &TextRun{
+					Font:      C.GoString(C.fz_font_name(ctx, ch.font)),
+					FontSize:  float64(ch.size),
+					FontFlags: fontFlags(ctx, ch.font),
+					Color:     color.NRGBA{R: uint8(ch.color >> 16), G: uint8(ch.color >> 8), B: uint8(ch.color), A: 255},
+				}
+				l.Runs = append(l.Runs, run)
+			}
+
+			quad := quadFromFitz(ch.quad)
+			run.Chars = append(run.Chars, TextChar{Rune: rune(ch.c), Origin: pointFromFitz(ch.origin), Quad: quad})
+			run.Bounds = unionRects(run.Bounds, rectFromFitz(C.fz_rect_from_quad(ch.quad)))
+		}
+
+		b.Lines = append(b.Lines, l)
+	}
+
+	return b
+}
+
+func sameStyle(ctx *C.fz_context, run *TextRun, ch *C.fz_stext_char) bool {
+	return float64(ch.size) == run.FontSize &&
+		C.GoString(C.fz_font_name(ctx, ch.font)) == run.Font &&
+		color.NRGBA{R: uint8(ch.color >> 16), G: uint8(ch.color >> 8), B: uint8(ch.color), A: 255} == run.Color
+}
+
+func fontFlags(ctx *C.fz_context, font *C.fz_font) (flags FontFlags) {
+	if C.fz_font_is_bold(ctx, font) != 0 {
+		flags |= FontBold
+	}
+	if C.fz_font_is_italic(ctx, font) != 0 {
+		flags |= FontItalic
+	}
+	if C.fz_font_is_serif(ctx, font) != 0 {
+		flags |= FontSerif
+	}
+	if C.fz_font_is_monospaced(ctx, font) != 0 {
+		flags |= FontMonospaced
+	}
+	return flags
+}
@@ -0,0 +1,422 @@
+package fitz
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// Table is a grid of cells detected from the ruling lines on a page.
+type Table struct {
+	Bounds gfx.Rect     `json:"bounds"`
+	Rows   int          `json:"rows"`
+	Cols   int          `json:"cols"`
+	Cells  []*TableCell `json:"cells"`
+}
+
+// TableCell is a cell of a table. Merged cells span several rows or
+// columns of the grid.
+type TableCell struct {
+	Row     int      `json:"row"`
+	Col     int      `json:"col"`
+	RowSpan int      `json:"rowSpan"`
+	ColSpan int      `json:"colSpan"`
+	Bounds  gfx.Rect `json:"bounds"`
+	Text    string   `json:"text"`
+}
+
+// Grid returns the text of the table as rows of columns. The text of a
+// merged cell appears at its top left position only.
+func (t *Table) Grid() [][]string {
+	grid := make([][]string, t.Rows)
+	for i := range grid {
+		grid[i] = make([]string, t.Cols)
+	}
+	for _, cell := range t.Cells {
+		grid[cell.Row][cell.Col] = cell.Text
+	}
+	return grid
+}
+
+// WriteCSV writes the grid of the table to w as CSV.
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(t.Grid()); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteJSON writes the table and its cells to w as JSON.
+func (t *Table) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t)
+}
+
+// TableDevice is a Device that collects ruling lines and text for table
+// detection. Thin filled or stroked shapes are taken as ruling lines. When
+// run by a page, the lines of stroked paths made of horizontal and vertical
+// lines only, such as rectangle outlines, are taken as well.
+type TableDevice struct {
+	LayoutDevice
+	horizontal []ruling
+	vertical   []ruling
+}
+
+// ruling is a horizontal or vertical line segment. For horizontal rulings
+// pos is the y coordinate and span the x extent, and the other way round
+// for vertical ones.
+type ruling struct {
+	pos  float64
+	span gfx.Interval
+}
+
+const (
+	// rulingWidth is the largest thickness of a shape taken as a line.
+	rulingWidth = 2.0
+	// rulingTolerance is how far apart lines may be and still meet.
+	rulingTolerance = 2.0
+)
+
+func NewTableDevice() *TableDevice {
+	return &TableDevice{}
+}
+
+func (dev *TableDevice) FillPath(path *gfx.Path, fillRule gfx.FillRule, ctm gfx.Matrix, fillColor color.Color) {
+	dev.addRuling(ctm.TransformRect(path.Bounds()))
+}
+
+// StrokePath takes thin stroked shapes as ruling lines. gfx.Path does not
+// expose its segments, so outlines are only recognised when the device is
+// run by a page.
+func (dev *TableDevice) StrokePath(path *gfx.Path, stroke *gfx.Stroke, ctm gfx.Matrix, strokeColor color.Color) {
+	dev.addRuling(ctm.TransformRect(path.Bounds()))
+}
+
+// rulingStroker is implemented by devices that take the segments of stroked
+// paths as ruling lines. The page's device bridge walks stroked paths for
+// them and calls strokeRulings in place of StrokePath.
+type rulingStroker interface {
+	strokeRulings(w *rulingWalker, bounds gfx.Rect)
+}
+
+func (dev *TableDevice) strokeRulings(w *rulingWalker, bounds gfx.Rect) {
+	if w.irregular {
+		dev.addRuling(bounds)
+		return
+	}
+	dev.horizontal = append(dev.horizontal, w.horizontal...)
+	dev.vertical = append(dev.vertical, w.vertical...)
+}
+
+func (dev *TableDevice) addRuling(r gfx.Rect) {
+	w, h := r.X.Max-r.X.Min, r.Y.Max-r.Y.Min
+	switch {
+	case h <= rulingWidth && w > rulingWidth:
+		dev.horizontal = append(dev.horizontal, ruling{pos: (r.Y.Min + r.Y.Max) / 2, span: r.X})
+	case w <= rulingWidth && h > rulingWidth:
+		dev.vertical = append(dev.vertical, ruling{pos: (r.X.Min + r.X.Max) / 2, span: r.Y})
+	}
+}
+
+// rulingWalker collects the segments of a path, transformed by ctm, as
+// rulings. A path with curves or slanted lines is irregular and its
+// segments are not rulings.
+type rulingWalker struct {
+	ctm                  gfx.Matrix
+	start, last          gfx.Point
+	horizontal, vertical []ruling
+	irregular            bool
+}
+
+func (w *rulingWalker) MoveTo(x, y float64) {
+	w.start = transformPoint(w.ctm, gfx.Point{X: x, Y: y})
+	w.last = w.start
+}
+
+func (w *rulingWalker) LineTo(x, y float64) {
+	w.lineTo(transformPoint(w.ctm, gfx.Point{X: x, Y: y}))
+}
+
+func (w *rulingWalker) CubicCurveTo(x1, y1, x2, y2, x3, y3 float64) {
+	w.irregular = true
+}
+
+func (w *rulingWalker) QuadCurveTo(x1, y1, x2, y2 float64) {
+	w.irregular = true
+}
+
+func (w *rulingWalker) Close() {
+	w.lineTo(w.start)
+}
+
+func (w *rulingWalker) lineTo(p gfx.Point) {
+	// how far a line may lean and still be horizontal or vertical
+	const slant = 0.01
+
+	a := w.last
+	w.last = p
+	dx, dy := math.Abs(p.X-a.X), math.Abs(p.Y-a.Y)
+	switch {
+	case dx <= slant && dy <= slant:
+	case dy <= slant:
+		w.horizontal = append(w.horizontal, ruling{pos: a.Y, span: gfx.Interval{Min: math.Min(a.X, p.X), Max: math.Max(a.X, p.X)}})
+	case dx <= slant:
+		w.vertical = append(w.vertical, ruling{pos: a.X, span: gfx.Interval{Min: math.Min(a.Y, p.Y), Max: math.Max(a.Y, p.Y)}})
+	default:
+		w.irregular = true
+	}
+}
+
+// Tables detects the tables among the collected rulings and fills their
+// cells with the collected text.
+func (dev *TableDevice) Tables() []*Table {
+	horizontal := mergeRulings(dev.horizontal)
+	vertical := mergeRulings(dev.vertical)
+
+	var tables []*Table
+	for _, group := range connectRulings(horizontal, vertical) {
+		if t := buildTable(group.horizontal, group.vertical); t != nil {
+			tables = append(tables, t)
+		}
+	}
+
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Bounds.Y.Min < tables[j].Bounds.Y.Min })
+
+	for _, t := range tables {
+		dev.fillCells(t)
+	}
+	return tables
+}
+
+// Tables detects the tables on the page from its ruling lines. Tables laid
+// out with whitespace only are not detected.
+func (p *Page) Tables() ([]*Table, error) {
+	dev := NewTableDevice()
+	if err := p.RunDevice(dev); err != nil {
+		return nil, err
+	}
+	return dev.Tables(), nil
+}
+
+// mergeRulings joins collinear rulings that overlap or touch. Positions
+// are clustered as by clusterPositions first, as comparing them within the
+// tolerance while sorting is not transitive.
+func mergeRulings(rulings []ruling) []ruling {
+	sort.Slice(rulings, func(i, j int) bool { return rulings[i].pos < rulings[j].pos })
+
+	var merged []ruling
+	for start := 0; start < len(rulings); {
+		end := start + 1
+		for end < len(rulings) && rulings[end].pos-rulings[start].pos <= rulingTolerance {
+			end++
+		}
+
+		cluster := rulings[start:end]
+		sort.SliceStable(cluster, func(i, j int) bool { return cluster[i].span.Min < cluster[j].span.Min })
+
+		first := len(merged)
+		for _, r := range cluster {
+			if n := len(merged); n > first && r.span.Min <= merged[n-1].span.Max+rulingTolerance {
+				merged[n-1].span.Max = math.Max(merged[n-1].span.Max, r.span.Max)
+				continue
+			}
+			merged = append(merged, r)
+		}
+		start = end
+	}
+	return merged
+}
+
+func crosses(h, v ruling) bool {
+	return v.pos >= h.span.Min-rulingTolerance && v.pos <= h.span.Max+rulingTolerance &&
+		h.pos >= v.span.Min-rulingTolerance && h.pos <= v.span.Max+rulingTolerance
+}
+
+type rulingGroup struct {
+	horizontal, vertical []ruling
+}
+
+// connectRulings splits the rulings into groups of lines that cross each
+// other.
+func connectRulings(horizontal, vertical []ruling) []rulingGroup {
+	parent := make([]int, len(horizontal)+len(vertical))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i, h := range horizontal {
+		for j, v := range vertical {
+			if crosses(h, v) {
+				parent[find(i)] = find(len(horizontal) + j)
+			}
+		}
+	}
+
+	groups := make(map[int]*rulingGroup)
+	var order []int
+	group := func(i int) *rulingGroup {
+		root := find(i)
+		if groups[root] == nil {
+			groups[root] = &rulingGroup{}
+			order = append(order, root)
+		}
+		return groups[root]
+	}
+
+	for i, h := range horizontal {
+		g := group(i)
+		g.horizontal = append(g.horizontal, h)
+	}
+	for j, v := range vertical {
+		g := group(len(horizontal) + j)
+		g.vertical = append(g.vertical, v)
+	}
+
+	out := make([]rulingGroup, 0, len(order))
+	for _, root := range order {
+		out = append(out, *groups[root])
+	}
+	return out
+}
+
+// buildTable lays a grid over a group of crossing rulings and merges cells
+// that have no ruling between them.
+func buildTable(horizontal, vertical []ruling) *Table {
+	ys := clusterPositions(horizontal)
+	xs := clusterPositions(vertical)
+	if len(ys) < 2 || len(xs) < 2 {
+		return nil
+	}
+
+	// a lone box, such as a framed paragraph, is not a table
+	rows, cols := len(ys)-1, len(xs)-1
+	if rows < 2 && cols < 2 {
+		return nil
+	}
+
+	// separated reports whether a ruling divides the two cells either side
+	// of the grid line at pos, along the given extent
+	separated := func(rulings []ruling, pos float64, lo, hi float64) bool {
+		mid := (lo + hi) / 2
+		for _, r := range rulings {
+			if math.Abs(r.pos-pos) <= rulingTolerance && r.span.Min-rulingTolerance <= mid && mid <= r.span.Max+rulingTolerance {
+				return true
+			}
+		}
+		return false
+	}
+
+	owner := make([][]int, rows)
+	for r := range owner {
+		owner[r] = make([]int, cols)
+		for c := range owner[r] {
+			owner[r][c] = -1
+		}
+	}
+
+	t := &Table{
+		Bounds: gfx.Rect{X: gfx.Interval{Min: xs[0], Max: xs[cols]}, Y: gfx.Interval{Min: ys[0], Max: ys[rows]}},
+		Rows:   rows,
+		Cols:   cols,
+	}
+
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if owner[r][c] >= 0 {
+				continue
+			}
+
+			colSpan := 1
+			for c+colSpan < cols && owner[r][c+colSpan] < 0 && !separated(vertical, xs[c+colSpan], ys[r], ys[r+1]) {
+				colSpan++
+			}
+
+			rowSpan := 1
+			for r+rowSpan < rows && !separated(horizontal, ys[r+rowSpan], xs[c], xs[c+colSpan]) {
+				rowSpan++
+			}
+
+			for i := r; i < r+rowSpan; i++ {
+				for j := c; j < c+colSpan; j++ {
+					owner[i][j] = len(t.Cells)
+				}
+			}
+
+			t.Cells = append(t.Cells, &TableCell{
+				Row:     r,
+				Col:     c,
+				RowSpan: rowSpan,
+				ColSpan: colSpan,
+				Bounds:  gfx.Rect{X: gfx.Interval{Min: xs[c], Max: xs[c+colSpan]}, Y: gfx.Interval{Min: ys[r], Max: ys[r+rowSpan]}},
+			})
+		}
+	}
+
+	// nor is one whose interior rulings divide no cells
+	if len(t.Cells) < 2 {
+		return nil
+	}
+	return t
+}
+
+// clusterPositions returns the distinct positions of the rulings, merging
+// those closer than the tolerance.
+func clusterPositions(rulings []ruling) []float64 {
+	pos := make([]float64, len(rulings))
+	for i, r := range rulings {
+		pos[i] = r.pos
+	}
+	sort.Float64s(pos)
+
+	var out []float64
+	for _, p := range pos {
+		if n := len(out); n > 0 && p-out[n-1] <= rulingTolerance {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func (dev *TableDevice) fillCells(t *Table) {
+	glyphs := make([][]layoutGlyph, len(t.Cells))
+	for _, g := range dev.glyphs {
+		x := (g.bounds.X.Min + g.bounds.X.Max) / 2
+		y := (g.bounds.Y.Min + g.bounds.Y.Max) / 2
+		for i, cell := range t.Cells {
+			if x >= cell.Bounds.X.Min && x < cell.Bounds.X.Max && y >= cell.Bounds.Y.Min && y < cell.Bounds.Y.Max {
+				glyphs[i] = append(glyphs[i], g)
+				break
+			}
+		}
+	}
+
+	for i, cell := range t.Cells {
+		if len(glyphs[i]) == 0 {
+			continue
+		}
+
+		layout := analyzeLayout(glyphs[i], LayoutOptions{}.withDefaults())
+		var paras []string
+		for _, col := range layout.Columns {
+			for _, para := range col.Paragraphs {
+				paras = append(paras, para.String())
+			}
+		}
+		cell.Text = strings.Join(paras, " ")
+	}
+}
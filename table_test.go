@@ -0,0 +1,158 @@
+package fitz_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bryanmatteson/fitz"
+
+	"github.com/bryanmatteson/gfx"
+)
+
+// line is a ruling line drawn from x0, y0 to x1, y1 as a filled rectangle
+// one point thick.
+type line struct {
+	x0, y0, x1, y1 float64
+}
+
+func (l line) path() *gfx.Path {
+	x0, y0, x1, y1 := l.x0, l.y0, l.x1, l.y1
+	if y0 == y1 {
+		y0, y1 = y0-0.5, y1+0.5
+	} else {
+		x0, x1 = x0-0.5, x1+0.5
+	}
+
+	p := &gfx.Path{}
+	p.MoveTo(x0, y0)
+	p.LineTo(x1, y0)
+	p.LineTo(x1, y1)
+	p.LineTo(x0, y1)
+	p.Close()
+	return p
+}
+
+func TestTableDevice(t *testing.T) {
+	// grid returns horizontal and vertical lines at each position, spanning
+	// lo to hi
+	grid := func(lo, hi float64, ys []float64, xs []float64) []line {
+		var lines []line
+		for _, y := range ys {
+			lines = append(lines, line{lo, y, hi, y})
+		}
+		for _, x := range xs {
+			lines = append(lines, line{x, lo, x, hi})
+		}
+		return lines
+	}
+	at := func(pos ...float64) []float64 { return pos }
+
+	// each cell is row, col, row span and column span
+	tests := []struct {
+		name       string
+		lines      []line
+		rows, cols int
+		cells      [][4]int
+	}{
+		{
+			name:  "grid",
+			lines: grid(0, 20, at(0, 10, 20), at(0, 10, 20)),
+			rows:  2,
+			cols:  2,
+			cells: [][4]int{{0, 0, 1, 1}, {0, 1, 1, 1}, {1, 0, 1, 1}, {1, 1, 1, 1}},
+		},
+		{
+			name:  "single row",
+			lines: append(grid(0, 30, at(0, 10), nil), grid(0, 10, nil, at(0, 10, 20, 30))...),
+			rows:  1,
+			cols:  3,
+			cells: [][4]int{{0, 0, 1, 1}, {0, 1, 1, 1}, {0, 2, 1, 1}},
+		},
+		{
+			name:  "merged header",
+			lines: append(grid(0, 20, at(0, 10, 20), at(0, 20)), line{10, 10, 10, 20}),
+			rows:  2,
+			cols:  2,
+			cells: [][4]int{{0, 0, 1, 2}, {1, 0, 1, 1}, {1, 1, 1, 1}},
+		},
+		{
+			name:  "merged column",
+			lines: append(grid(0, 20, at(0, 20), at(0, 10, 20)), line{10, 10, 20, 10}),
+			rows:  2,
+			cols:  2,
+			cells: [][4]int{{0, 0, 2, 1}, {0, 1, 1, 1}, {1, 1, 1, 1}},
+		},
+		{
+			// the middle lines are drawn in pieces that do not quite line up
+			name: "pieces",
+			lines: append(grid(0, 20, at(0, 20), at(0, 20)),
+				line{0, 10, 12, 10}, line{11, 11, 20, 11},
+				line{10, 0, 10, 12}, line{11, 11, 11, 20}),
+			rows:  2,
+			cols:  2,
+			cells: [][4]int{{0, 0, 1, 1}, {0, 1, 1, 1}, {1, 0, 1, 1}, {1, 1, 1, 1}},
+		},
+		{
+			name:  "box",
+			lines: grid(0, 50, at(0, 50), at(0, 50)),
+		},
+		{
+			name:  "box with a stub",
+			lines: append(grid(0, 50, at(0, 50), at(0, 50)), line{25, 0, 25, 3}),
+		},
+		{
+			name:  "lone line",
+			lines: append(grid(0, 50, nil, at(0, 50)), line{0, 0, 50, 0}),
+		},
+	}
+
+	for _, tt := range tests {
+		dev := fitz.NewTableDevice()
+		for _, l := range tt.lines {
+			dev.FillPath(l.path(), gfx.FillRuleWinding, gfx.IdentityMatrix, nil)
+		}
+
+		tables := dev.Tables()
+		if tt.cells == nil {
+			if len(tables) != 0 {
+				t.Errorf("%s: Tables() = %d tables, want none", tt.name, len(tables))
+			}
+			continue
+		}
+		if len(tables) != 1 {
+			t.Errorf("%s: Tables() = %d tables, want 1", tt.name, len(tables))
+			continue
+		}
+
+		table := tables[0]
+		if table.Rows != tt.rows || table.Cols != tt.cols {
+			t.Errorf("%s: Tables() = %d×%d table, want %d×%d", tt.name, table.Rows, table.Cols, tt.rows, tt.cols)
+		}
+		var cells [][4]int
+		for _, c := range table.Cells {
+			cells = append(cells, [4]int{c.Row, c.Col, c.RowSpan, c.ColSpan})
+		}
+		if !reflect.DeepEqual(cells, tt.cells) {
+			t.Errorf("%s: Tables() cells = %v, want %v", tt.name, cells, tt.cells)
+		}
+	}
+}
+
+func TestPageTables(t *testing.T) {
+	// the table of the sample is drawn as four stroked rectangles
+	tables, err := loadPage(t, openSample(t), 0).Tables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 {
+		t.Fatalf("Tables() = %d tables, want 1", len(tables))
+	}
+
+	table := tables[0]
+	if want := rectWH(72, 232, 200, 60); !rectNear(table.Bounds, want) {
+		t.Errorf("Tables() bounds = %v, want %v", table.Bounds, want)
+	}
+	if grid, want := table.Grid(), [][]string{{"Name", "Value"}, {"Apples", "42"}}; !reflect.DeepEqual(grid, want) {
+		t.Errorf("Tables() grid = %q, want %q", grid, want)
+	}
+}
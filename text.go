@@ -1,6 +1,7 @@
 package fitz
 
 import (
+	"image/color"
 	"strings"
 
 	"github.com/bryanmatteson/gfx"
@@ -12,6 +13,15 @@ type TextSpan struct {
 	Matrix  gfx.Matrix
 	WMode   int
 	Quad    gfx.Quad
+	// Color is nil for clipping and invisible text.
+	Color color.Color
+	Alpha float64
+	// Size is the font size on the page, after the device transform.
+	Size       float64
+	RenderMode RenderMode
+	// BidiLevel is the Unicode bidirectional embedding level; odd levels
+	// are right-to-left.
+	BidiLevel int
 }
 
 func (s *TextSpan) String() string {
@@ -27,6 +37,19 @@ type Text struct {
 	Spans     []*TextSpan
 }
 
+// RenderMode is how a text span was drawn.
+type RenderMode int
+
+const (
+	RenderFill RenderMode = iota
+	RenderStroke
+	RenderClip
+	RenderClipStroke
+	// RenderInvisible is text that is not drawn at all, such as the text
+	// layer of a scanned document.
+	RenderInvisible
+)
+
 // Writing modes
 const (
 	WModeHorizontal int = iota
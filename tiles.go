@@ -0,0 +1,139 @@
+package fitz
+
+// #include "bridge.h"
+import "C"
+import (
+	"context"
+	"image"
+	"io"
+	"math"
+	"unsafe"
+)
+
+// TileOptions controls tiled rendering.
+type TileOptions struct {
+	RenderOptions
+	// TileWidth and TileHeight are the size of a tile in pixels. Tiles on
+	// the right and bottom edges may be smaller. A zero TileWidth renders
+	// bands the full width of the page. TileHeight defaults to 256.
+	TileWidth, TileHeight int
+}
+
+const defaultTileHeight = 256
+
+// Tile is a rendered part of a page image.
+type Tile struct {
+	Row, Col int
+	// Bounds is the position of the tile in the full page image. The
+	// image of the tile has the same bounds.
+	Bounds image.Rectangle
+	Image  image.Image
+}
+
+// RenderTiles renders the page one tile at a time from its display list
+// and calls fn with each, row by row. Only one tile is held in memory at a
+// time. If fn returns an error, rendering stops and the error is returned.
+func (p *Page) RenderTiles(opts TileOptions, fn func(Tile) error) error {
+	return p.RenderTilesContext(context.Background(), opts, fn)
+}
+
+// RenderTilesContext is like RenderTiles but stops when ctx is done.
+func (p *Page) RenderTilesContext(ctx context.Context, opts TileOptions, fn func(Tile) error) error {
+	ctm, bbox := p.renderTransform(opts.RenderOptions)
+	full := image.Rect(int(bbox.x0), int(bbox.y0), int(bbox.x1), int(bbox.y1))
+
+	width, height := opts.TileWidth, opts.TileHeight
+	if width <= 0 {
+		width = full.Dx()
+	}
+	if height <= 0 {
+		height = defaultTileHeight
+	}
+
+	for row, y := 0, full.Min.Y; y < full.Max.Y; row, y = row+1, y+height {
+		for col, x := 0, full.Min.X; x < full.Max.X; col, x = col+1, x+width {
+			r := image.Rect(x, y, x+width, y+height).Intersect(full)
+			tile := C.fz_make_irect(C.int(r.Min.X), C.int(r.Min.Y), C.int(r.Max.X), C.int(r.Max.Y))
+			bounds := r.Sub(full.Min)
+
+			img, err := p.renderRect(ctx, ctm, tile, opts.RenderOptions, bounds.Min)
+			if err != nil {
+				return err
+			}
+			if err := fn(Tile{Row: row, Col: col, Bounds: bounds, Image: img}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// WritePNG renders the page as a PNG to w in bands of opts.TileHeight rows,
+// so that memory use stays bounded however large the image. TileWidth is
+// ignored. Only RGB and gray output can be written as PNG.
+func (p *Page) WritePNG(w io.Writer, opts TileOptions) error {
+	return p.WritePNGContext(context.Background(), w, opts)
+}
+
+// WritePNGContext is like WritePNG but stops when ctx is done.
+func (p *Page) WritePNGContext(ctx context.Context, w io.Writer, opts TileOptions) (err error) {
+	n, alpha := C.int(4), C.int(1)
+	switch opts.Colorspace {
+	case ColorspaceRGB:
+	case ColorspaceGray:
+		n, alpha = 1, 0
+	default:
+		return ErrColorspace
+	}
+
+	opts.TileWidth = 0
+	ctm, bbox := p.renderTransform(opts.RenderOptions)
+
+	// fitting to Width or Height changes the scale, so take the resolution
+	// from the transform rather than from DPI
+	res := C.int(math.Round(float64(C.fz_matrix_expansion(ctm)) * 72))
+
+	defer catch(&err)
+
+	output, writer := newStreamOutputForWriter(p.ctx, 8192, w)
+	defer C.fz_drop_output(p.ctx, output)
+	defer func() {
+		C.fz_close_output(p.ctx, output)
+		if err == nil {
+			err = writer.err
+		}
+	}()
+	defer writer.catch(&err)
+
+	band := C.fz_new_png_band_writer(p.ctx, output)
+	defer C.fz_drop_band_writer(p.ctx, band)
+
+	C.fz_write_header(p.ctx, band, bbox.x1-bbox.x0, bbox.y1-bbox.y0, n, alpha, res, res, 0, opts.Colorspace.fitz(p.ctx), nil)
+
+	err = p.RenderTilesContext(ctx, opts, func(t Tile) error {
+		pix, stride, err := tilePixels(t.Image)
+		if err != nil {
+			return err
+		}
+
+		p.mut.Lock()
+		defer p.mut.Unlock()
+
+		C.fz_write_band(p.ctx, band, C.int(stride), C.int(t.Bounds.Dy()), (*C.uchar)(unsafe.Pointer(&pix[0])))
+		return writer.err
+	})
+	return err
+}
+
+func tilePixels(img image.Image) ([]byte, int, error) {
+	switch img := img.(type) {
+	case *image.Gray:
+		return img.Pix, img.Stride, nil
+	case *image.CMYK:
+		return img.Pix, img.Stride, nil
+	case *image.RGBA:
+		return img.Pix, img.Stride, nil
+	}
+	return nil, 0, ErrColorspace
+}
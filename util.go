@@ -15,6 +15,36 @@ func rectFromFitz(rect C.fz_rect) gfx.Rect {
 	return gfx.MakeRect(float64(rect.x0), float64(rect.y0), float64(rect.x1), float64(rect.y1))
 }
 
+func rectToFitz(rect gfx.Rect) C.fz_rect {
+	return C.fz_make_rect(C.float(rect.X.Min), C.float(rect.Y.Min), C.float(rect.X.Max), C.float(rect.Y.Max))
+}
+
+func pointFromFitz(pt C.fz_point) gfx.Point {
+	return gfx.MakePoint(float64(pt.x), float64(pt.y))
+}
+
+func pointToFitz(pt gfx.Point) C.fz_point {
+	return C.fz_make_point(C.float(pt.X), C.float(pt.Y))
+}
+
+func quadFromFitz(q C.fz_quad) gfx.Quad {
+	return gfx.Quad{
+		TopLeft:     pointFromFitz(q.ul),
+		TopRight:    pointFromFitz(q.ur),
+		BottomLeft:  pointFromFitz(q.ll),
+		BottomRight: pointFromFitz(q.lr),
+	}
+}
+
+func quadToFitz(q gfx.Quad) C.fz_quad {
+	return C.fz_quad{
+		ul: pointToFitz(q.TopLeft),
+		ur: pointToFitz(q.TopRight),
+		ll: pointToFitz(q.BottomLeft),
+		lr: pointToFitz(q.BottomRight),
+	}
+}
+
 func matrixFromFitz(trm C.fz_matrix) gfx.Matrix {
 	return gfx.NewMatrix(float64(trm.a), float64(trm.b), float64(trm.c), float64(trm.d), float64(trm.e), float64(trm.f))
 }
@@ -28,6 +58,57 @@ func pdfName(ename C.int) *C.pdf_obj {
 	return C.pdfname(ename)
 }
 
+// isExternalLink reports whether uri has a scheme, mirroring
+// fz_is_external_link.
+func isExternalLink(uri string) bool {
+	for i, r := range uri {
+		switch {
+		case r == ':':
+			return i > 0
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func pdfCatalog(ctx *C.fz_context, doc *C.pdf_document) *C.pdf_obj {
+	return C.pdf_dict_get(ctx, C.pdf_trailer(ctx, doc), pdfName(C.PDF_ENUM_NAME_Root))
+}
+
+// pdfLinkDest creates an explicit destination array pointing at pt on the
+// given page. pt is expressed in fitz page space and is converted back to
+// the page's PDF user space.
+func pdfLinkDest(ctx *C.fz_context, doc *C.pdf_document, page int, pt gfx.Point) *C.pdf_obj {
+	pageObj := C.pdf_lookup_page_obj(ctx, doc, C.int(page))
+
+	var mediabox C.fz_rect
+	var ctm C.fz_matrix
+	C.pdf_page_obj_transform(ctx, pageObj, &mediabox, &ctm)
+	p := C.fz_transform_point(C.fz_make_point(C.float(pt.X), C.float(pt.Y)), C.fz_invert_matrix(ctm))
+
+	dest := C.pdf_new_array(ctx, doc, 5)
+	C.pdf_array_push(ctx, dest, pageObj)
+	C.pdf_array_push(ctx, dest, pdfName(C.PDF_ENUM_NAME_XYZ))
+	C.pdf_array_push_real(ctx, dest, C.double(p.x))
+	C.pdf_array_push_real(ctx, dest, C.double(p.y))
+	C.pdf_array_push_real(ctx, dest, 0)
+	return dest
+}
+
+// pdfURIAction creates a URI action dictionary for an external link.
+func pdfURIAction(ctx *C.fz_context, doc *C.pdf_document, uri string) *C.pdf_obj {
+	curi := C.CString(uri)
+	defer C.free(unsafe.Pointer(curi))
+
+	action := C.pdf_new_dict(ctx, doc, 2)
+	C.pdf_dict_put(ctx, action, pdfName(C.PDF_ENUM_NAME_S), pdfName(C.PDF_ENUM_NAME_URI))
+	C.pdf_dict_put_string(ctx, action, pdfName(C.PDF_ENUM_NAME_URI), curi, C.size_t(len(uri)))
+	return action
+}
+
 func getRGBColor(ctx *C.fz_context, col *C.float, colorspace *C.fz_colorspace, alpha C.float, params C.fz_color_params) color.NRGBA {
 	var rgb [3]C.float
 	if C.fz_colorspace_is_rgb(ctx, colorspace) == 0 {
@@ -44,6 +125,52 @@ func getRGBColor(ctx *C.fz_context, col *C.float, colorspace *C.fz_colorspace, a
 	}
 }
 
+func unitToByte(v C.float) byte {
+	return byte(C.fz_clampi(C.int(v*255), 0, 255))
+}
+
+// colorFromFitz converts n gray, rgb or cmyk components to a color. No
+// components means no color.
+func colorFromFitz(n int, c []C.float) color.Color {
+	switch n {
+	case 1:
+		return color.Gray{Y: unitToByte(c[0])}
+	case 3:
+		return color.NRGBA{R: unitToByte(c[0]), G: unitToByte(c[1]), B: unitToByte(c[2]), A: 255}
+	case 4:
+		return color.CMYK{C: unitToByte(c[0]), M: unitToByte(c[1]), Y: unitToByte(c[2]), K: unitToByte(c[3])}
+	}
+	return nil
+}
+
+// colorToFitz converts col to gray, cmyk or rgb components, preserving the
+// color model of gray and cmyk colors. A nil color has no components.
+func colorToFitz(col color.Color) (C.int, [4]C.float) {
+	var c [4]C.float
+	switch col := col.(type) {
+	case nil:
+		return 0, c
+	case color.Gray:
+		c[0] = C.float(col.Y) / 255
+		return 1, c
+	case color.CMYK:
+		c[0], c[1], c[2], c[3] = C.float(col.C)/255, C.float(col.M)/255, C.float(col.Y)/255, C.float(col.K)/255
+		return 4, c
+	}
+
+	rgb := rgbToFitz(col)
+	copy(c[:], rgb[:])
+	return 3, c
+}
+
+func rgbToFitz(col color.Color) [3]C.float {
+	if col == nil {
+		return [3]C.float{}
+	}
+	nrgba := color.NRGBAModel.Convert(col).(color.NRGBA)
+	return [3]C.float{C.float(nrgba.R) / 255, C.float(nrgba.G) / 255, C.float(nrgba.B) / 255}
+}
+
 func getStroke(stroke *C.fz_stroke_state) *gfx.Stroke {
 	dashes := make([]float64, int(stroke.dash_len))
 	for i := 0; i < int(stroke.dash_len); i++ {
@@ -130,7 +257,7 @@ func getImage(ctx *C.fz_context, img *C.fz_image, colorParams C.fz_color_params)
 	panic("??")
 }
 
-func getTextInfo(ctx *C.fz_context, fztext *C.fz_text, ctm C.fz_matrix, col color.Color) (text *Text) {
+func getTextInfo(ctx *C.fz_context, fztext *C.fz_text, ctm C.fz_matrix, col color.Color, alpha float64, mode RenderMode) (text *Text) {
 	userCtx := pointer.Restore(unsafe.Pointer(ctx.user)).(*usercontext)
 	text = &Text{FontCache: userCtx.fontCache}
 
@@ -199,11 +326,16 @@ func getTextInfo(ctx *C.fz_context, fztext *C.fz_text, ctm C.fz_matrix, col colo
 		}
 
 		text.Spans = append(text.Spans, &TextSpan{
-			Font:    getFont(ctx, span.font),
-			WMode:   wmode,
-			Letters: letters,
-			Matrix:  spanmat,
-			Quad:    quads.Union(),
+			Font:       getFont(ctx, span.font),
+			WMode:      wmode,
+			Letters:    letters,
+			Matrix:     spanmat,
+			Quad:       quads.Union(),
+			Color:      col,
+			Alpha:      alpha,
+			Size:       float64(C.fz_matrix_expansion(C.fz_concat(span.trm, ctm))),
+			RenderMode: mode,
+			BidiLevel:  int(C.fz_text_span_bidi_level(span)),
 		})
 	}
 	return